@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// runAttach draws a read-only copy of the bars of a running daemon, for
+// example from another terminal than the one the job runs in.
+func runAttach(args []string) int {
+	fs := flag.NewFlagSet("attach", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pbar attach --socket <path> [flags]\n")
+		fs.PrintDefaults()
+	}
+
+	socket := fs.String("socket", "", "Path of the Unix socket the daemon listens on")
+	interval := fs.Duration("interval", defaultTickInterval, "Time between redraws")
+	fs.Parse(args)
+
+	if *socket == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	manager := pbar.NewManager()
+	manager.Mirror = true
+	detached := make(chan error, 1)
+	go func() {
+		detached <- pbar.Attach(*socket, func(update pbar.Update) {
+			manager.UpdateBar(update)
+		})
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	fmt.Print("\033[?25l") // Hide cursor
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	var err error
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+			manager.LineWidth = lineWidthOf(os.Stdout) // Follows terminal resizes
+			manager.RenderAll()
+		case err = <-detached:
+			running = false
+		case <-signals:
+			running = false
+		}
+	}
+	manager.RenderAll()
+	fmt.Print("\n\033[?25h") // Show cursor
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not attach to the daemon on %s: %v\n", *socket, err)
+		return 1
+	}
+	return 0
+}
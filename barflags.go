@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// barFlags holds the appearance flags shared by the subcommands that drive a
+// single bar on their own.
+type barFlags struct {
+	width           string
+	style           string
+	colorBarName    string
+	colorTextName   string
+	customChars     string
+	format          string
+	message         string
+	finishedMessage string
+	showElapsed     bool
+	showThroughput  bool
+	showETA         bool
+	interval        time.Duration
+}
+
+func addBarFlags(fs *flag.FlagSet) *barFlags {
+	f := &barFlags{}
+	fs.StringVar(&f.width, "width", strconv.Itoa(defaultWidth), widthUsage)
+	fs.StringVar(&f.style, "style", defaultStyle, "Style of the progress bar (classic, block, spinner, arrow, braille, custom, braille-spinner)")
+	fs.StringVar(&f.colorBarName, "colorbar", "", fmt.Sprintf("Color for the bar. Available: %s", pbar.GetAvailableColors()))
+	fs.StringVar(&f.colorTextName, "colortext", "", fmt.Sprintf("Color for the text. Available: %s", pbar.GetAvailableColors()))
+	fs.StringVar(&f.customChars, "chars", "", "Custom fill, empty and optional head characters for the progress bar (e.g., '#=' or '=->')")
+	fs.StringVar(&f.message, "message", "", "Optional message to display alongside the progress bar")
+	fs.StringVar(&f.finishedMessage, "finished-message", "", "Message to display when the progress bar is complete")
+	fs.BoolVar(&f.showElapsed, "show-elapsed", true, "Show elapsed time (default: true)")
+	fs.BoolVar(&f.showThroughput, "show-throughput", true, "Show throughput (iterations/second) (default: true)")
+	fs.BoolVar(&f.showETA, "show-eta", true, "Show estimated time remaining (default: true)")
+	fs.DurationVar(&f.interval, "interval", defaultTickInterval, "Time between redraws")
+	return f
+}
+
+// addFormatFlag adds --format to the flags. It is separate from addBarFlags
+// because exec uses --format for its presets.
+func (f *barFlags) addFormatFlag(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "", formatUsage)
+}
+
+// newBar validates the flags and returns a started bar configured from them.
+func (f *barFlags) newBar(total int64) (*pbar.Bar, error) {
+	if !isValidStyle(f.style) {
+		return nil, fmt.Errorf("invalid style '%s'. Must be one of: classic, block, spinner, arrow, braille, custom, braille-spinner", f.style)
+	}
+	width, lineWidth, auto, err := barWidth(f.width, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if f.format != "" {
+		if _, err := pbar.ParseFormat(f.format); err != nil {
+			return nil, err
+		}
+	}
+	return &pbar.Bar{
+		Total:             total,
+		Width:             width,
+		LineWidth:         lineWidth,
+		AutoWidth:         auto,
+		Style:             f.style,
+		ColorBar:          pbar.GetColorCode(f.colorBarName),
+		ColorText:         pbar.GetColorCode(f.colorTextName),
+		CustomChars:       f.customChars,
+		Format:            f.format,
+		Message:           f.message,
+		CompletionMessage: f.finishedMessage,
+		ShowElapsed:       f.showElapsed,
+		ShowThroughput:    f.showThroughput,
+		ShowETA:           f.showETA,
+		StartTime:         time.Now(),
+	}, nil
+}
+
+// formatUsage is the help of the --format flag.
+var formatUsage = fmt.Sprintf("Layout of the line, e.g. '{message:20} {bar} {percent:.1f} {eta}' (default %q). Fields: %s", pbar.DefaultFormat, strings.Join(pbar.FormatFields(), ", "))
+
+const widthUsage = "Width of the progress bar: a number of cells, a percentage of the terminal width (e.g. 60%), or auto to fill the line"
+
+// barWidth resolves a --width value for a bar drawn on out. It returns the
+// bar width, the number of columns the line must fit in (0 if unknown) and
+// whether the bar should fill the line. The width is always positive, as
+// ParseWidth rejects any other number and an auto width starts at
+// defaultWidth, so the modes that draw a bar themselves need no further
+// check.
+func barWidth(spec string, out *os.File) (width, lineWidth int, auto bool, err error) {
+	lineWidth = lineWidthOf(out)
+	width, auto, err = pbar.ParseWidth(spec, pbar.TerminalWidth(out))
+	if auto {
+		width = defaultWidth // Used when the terminal width is unknown
+	}
+	return width, lineWidth, auto, err
+}
+
+// lineWidthOf returns the number of columns a line drawn on out may use, or
+// 0 if out is not a terminal of known size. The last column is left free,
+// since filling it makes some terminals wrap and breaks the redraw.
+func lineWidthOf(out *os.File) int {
+	if columns := pbar.TerminalWidth(out); columns > 1 {
+		return columns - 1
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// runDaemon owns the terminal and a Manager, and draws the bars that other
+// pbar invocations send to it over a Unix socket.
+func runDaemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pbar daemon --socket <path> [flags]\n")
+		fs.PrintDefaults()
+	}
+
+	socket := fs.String("socket", "", "Path of the Unix socket to listen on")
+	interval := fs.Duration("interval", defaultTickInterval, "Time between redraws")
+	metricsPath := fs.String("metrics-file", "", "Rewrite this file with the bars in the Prometheus text format after each redraw")
+	listen := fs.String("listen", "", "Serve the bars over HTTP on this address (e.g. 127.0.0.1:9099): JSON at /bars, Server-Sent Events at /events, Prometheus metrics at /metrics")
+	fs.Parse(args)
+
+	if *socket == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	l, err := listenUnix(*socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer os.Remove(*socket)
+
+	manager := pbar.NewManager()
+	manager.AutoFinish = true // Clients may only send increments
+	metrics := newMetricsFile(*metricsPath)
+	server := &pbar.Server{Manager: manager}
+	go server.Serve(l)
+	if *listen != "" {
+		if err := serveStatus(*listen, manager); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	fmt.Print("\033[?25l") // Hide cursor
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+			manager.LineWidth = lineWidthOf(os.Stdout) // Follows terminal resizes
+			manager.RenderAll()
+			metrics.write(manager)
+		case <-signals:
+			running = false
+		}
+	}
+	l.Close()
+	manager.RenderAll()
+	metrics.write(manager)
+	fmt.Print("\n\033[?25h") // Show cursor
+	return 0
+}
+
+// listenUnix listens on a Unix socket at path that only the current user
+// can connect to. A socket left behind by a daemon that is no longer
+// running is replaced.
+func listenUnix(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
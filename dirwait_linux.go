@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// newDirWaiter returns a function that blocks until dir changes or the
+// timeout elapses, using inotify so new files are noticed immediately. It
+// falls back to plain polling if inotify is unavailable.
+func newDirWaiter(dir string) (wait func(timeout time.Duration), closeWaiter func()) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return time.Sleep, func() {}
+	}
+	mask := uint32(syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_DELETE)
+	if _, err := syscall.InotifyAddWatch(fd, dir, mask); err != nil {
+		syscall.Close(fd)
+		return time.Sleep, func() {}
+	}
+
+	// A non-blocking descriptor is handled by the runtime poller, which lets
+	// read deadlines act as the timeout
+	events := os.NewFile(uintptr(fd), "inotify")
+	buf := make([]byte, 4096)
+	wait = func(timeout time.Duration) {
+		events.SetReadDeadline(time.Now().Add(timeout))
+		if _, err := events.Read(buf); err != nil && !os.IsTimeout(err) {
+			time.Sleep(timeout)
+		}
+	}
+	return wait, func() { events.Close() }
+}
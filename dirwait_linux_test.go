@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirWaiter(t *testing.T) {
+	t.Run("returns when a file is created", func(t *testing.T) {
+		dir := t.TempDir()
+		wait, closeWaiter := newDirWaiter(dir)
+		defer closeWaiter()
+
+		created := make(chan struct{})
+		go func() {
+			defer close(created)
+			time.Sleep(50 * time.Millisecond)
+			if err := os.WriteFile(filepath.Join(dir, "frame_01.png"), nil, 0644); err != nil {
+				t.Errorf("Failed to create file: %v", err)
+			}
+		}()
+		defer func() { <-created }()
+
+		start := time.Now()
+		wait(10 * time.Second)
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the wait to end when the file was created, took %s", elapsed)
+		}
+	})
+
+	t.Run("times out without changes", func(t *testing.T) {
+		wait, closeWaiter := newDirWaiter(t.TempDir())
+		defer closeWaiter()
+
+		start := time.Now()
+		wait(50 * time.Millisecond)
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+			t.Errorf("Expected the wait to last the 50ms timeout, took %s", elapsed)
+		}
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "time"
+
+// newDirWaiter returns a function that waits for the next poll. Change
+// notifications are only used on Linux.
+func newDirWaiter(dir string) (wait func(timeout time.Duration), closeWaiter func()) {
+	return time.Sleep, func() {}
+}
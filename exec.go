@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// runExec runs a child command and drives a bar from progress readings found
+// in its output. Lines without a reading are passed through above the bar.
+func runExec(args []string) int {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	fs.SetInterspersed(false)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pbar exec (--match <regex> | --format <preset>) [flags] -- <command> [args...]\n")
+		fs.PrintDefaults()
+	}
+
+	bf := addBarFlags(fs)
+	match := fs.String("match", "", "Regular expression capturing current and total, or a percentage, from the command's output")
+	format := fs.String("format", "", fmt.Sprintf("Parse the native progress output of a known tool. Available: %s", strings.Join(pbar.PresetNames(), ", ")))
+	totalSpec := fs.String("total", "0", "Total to use when the output only carries current values (e.g. 340, or a size such as 4G for byte counts)")
+	fs.Parse(args)
+
+	cmdArgs := fs.Args()
+	if len(cmdArgs) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var parser pbar.LineParser
+	switch {
+	case *match != "" && *format != "":
+		fmt.Fprintf(os.Stderr, "Error: --match and --format cannot be combined\n")
+		return 2
+	case *match != "":
+		regexParser, err := pbar.NewRegexParser(*match)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid --match expression: %v\n", err)
+			return 2
+		}
+		parser = regexParser
+	case *format != "":
+		presetParser, err := pbar.NewPresetParser(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		parser = presetParser
+	default:
+		fmt.Fprintf(os.Stderr, "Error: One of --match or --format is required\n")
+		return 2
+	}
+	// Both output streams share the parser, which may keep state between lines
+	parser = &lockedParser{parser: parser}
+
+	total, err := pbar.ParseSize(*totalSpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --total: %v\n", err)
+		return 2
+	}
+	bar, err := bf.newBar(total)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Stdin = os.Stdin
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ticker := pbar.NewTicker(bar, os.Stderr, bf.interval)
+	fmt.Fprint(os.Stderr, "\033[?25l") // Hide cursor
+	ticker.Start()
+
+	err = runChild(cmd, func() {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			scanProgress(stdout, os.Stdout, parser, ticker)
+		}()
+		go func() {
+			defer wg.Done()
+			scanProgress(stderr, os.Stderr, parser, ticker)
+		}()
+		wg.Wait()
+	})
+	exitCode := exitCodeOf(err)
+
+	ticker.Update(func(b *pbar.Bar) {
+		if exitCode == 0 {
+			b.Finished = true
+		} else {
+			b.Fail(describeFailure(cmdArgs[0], err, exitCode))
+		}
+	})
+	ticker.Stop()
+	fmt.Fprint(os.Stderr, "\033[?25h") // Show cursor
+	return exitCode
+}
+
+// lockedParser serialises calls to a parser that is not safe for concurrent use.
+type lockedParser struct {
+	mu     sync.Mutex
+	parser pbar.LineParser
+}
+
+func (p *lockedParser) ParseLine(line string) (pbar.Progress, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.parser.ParseLine(line)
+}
+
+// scanProgress feeds every reading found in r to the ticker's bar and passes
+// the other lines through to w.
+func scanProgress(r io.Reader, w io.Writer, parser pbar.LineParser, ticker *pbar.Ticker) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(pbar.ScanProgressLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if progress, ok := parser.ParseLine(line); ok {
+			ticker.Update(progress.Apply)
+			continue
+		}
+		ticker.Println(w, line)
+	}
+	// Keep draining so the child never blocks on a full pipe
+	io.Copy(io.Discard, r)
+}
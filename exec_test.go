@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+func TestScanProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		output  string
+		current int64
+	}{
+		{
+			name:    "other lines pass through",
+			input:   "building\n10/100\ncompiling \x1b[1mmain\x1b[0m\r\n50/100\n\ndone\n",
+			output:  "building\ncompiling \x1b[1mmain\x1b[0m\n\ndone\n",
+			current: 50,
+		},
+		{
+			name:    "trailing line without a newline",
+			input:   "10/100\nlast words",
+			output:  "last words\n",
+			current: 10,
+		},
+		{
+			name:    "trailing reading without a newline",
+			input:   "starting\n10/100\r20/100\r75/100",
+			output:  "starting\n",
+			current: 75,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regexParser, err := pbar.NewRegexParser(`^(\d+)/(\d+)$`)
+			if err != nil {
+				t.Fatalf("NewRegexParser failed: %v", err)
+			}
+			var terminal, output bytes.Buffer
+			ticker := pbar.NewTicker(&pbar.Bar{Width: 10}, &terminal, time.Hour)
+
+			// Reading a byte at a time splits lines across reads
+			scanProgress(iotest.OneByteReader(strings.NewReader(tt.input)), &output, &lockedParser{parser: regexParser}, ticker)
+
+			if output.String() != tt.output {
+				t.Errorf("Expected output %q, got %q", tt.output, output.String())
+			}
+			ticker.Update(func(b *pbar.Bar) {
+				if b.Current != tt.current || b.Total != 100 {
+					t.Errorf("Expected the bar at %d/100, got %d/%d", tt.current, b.Current, b.Total)
+				}
+			})
+		})
+	}
+}
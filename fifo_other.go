@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package main
+
+import "errors"
+
+// makeFIFO reports that named pipes cannot be created on this platform.
+func makeFIFO(path string) error {
+	return errors.New("named pipes are not supported on this platform")
+}
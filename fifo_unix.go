@@ -0,0 +1,10 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package main
+
+import "syscall"
+
+// makeFIFO creates a named pipe that only the current user can access.
+func makeFIFO(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// inputSource is a stream of updates for parallel mode.
+type inputSource struct {
+	name    string
+	open    func() (io.ReadCloser, error)
+	created bool // A named pipe made by parallelInputs, to be removed by removeCreated
+}
+
+// inputLine is a line read from an inputSource. A line with err set is the
+// last one sent for its source.
+type inputLine struct {
+	source string
+	num    int
+	text   []byte
+	err    error
+}
+
+// parallelInputs returns the sources named by --input and --input-fd, or
+// stdin if there are none. Missing --input paths are created as named pipes
+// so that writers can open them as soon as this returns, and are removed by
+// removeCreated.
+func parallelInputs(paths []string, fds []int) ([]inputSource, error) {
+	var sources []inputSource
+	for _, path := range paths {
+		if path == "-" {
+			sources = append(sources, stdinSource())
+			continue
+		}
+		created := false
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := makeFIFO(path); err != nil {
+				removeCreated(sources)
+				return nil, fmt.Errorf("cannot create named pipe %s: %w", path, err)
+			}
+			created = true
+		}
+		// Opening a named pipe blocks until a writer shows up, so it is
+		// left to the reading goroutine.
+		sources = append(sources, inputSource{
+			name:    path,
+			open:    func() (io.ReadCloser, error) { return os.Open(path) },
+			created: created,
+		})
+	}
+	for _, fd := range fds {
+		name := fmt.Sprintf("fd %d", fd)
+		f := os.NewFile(uintptr(fd), name)
+		if f == nil {
+			removeCreated(sources)
+			return nil, fmt.Errorf("invalid descriptor %d", fd)
+		}
+		if _, err := f.Stat(); err != nil {
+			removeCreated(sources)
+			return nil, fmt.Errorf("descriptor %d is not open", fd)
+		}
+		sources = append(sources, inputSource{
+			name: name,
+			open: func() (io.ReadCloser, error) { return f, nil },
+		})
+	}
+	if len(sources) == 0 {
+		sources = append(sources, stdinSource())
+	}
+	return sources, nil
+}
+
+// removeCreated removes the named pipes that parallelInputs created for
+// sources, leaving the files that were there before.
+func removeCreated(sources []inputSource) {
+	for _, source := range sources {
+		if source.created {
+			os.Remove(source.name)
+		}
+	}
+}
+
+func stdinSource() inputSource {
+	return inputSource{
+		name: "stdin",
+		open: func() (io.ReadCloser, error) { return os.Stdin, nil },
+	}
+}
+
+// readInputs reads lines from all sources concurrently. The returned
+// channel is closed once every source has reached its end.
+func readInputs(sources []inputSource) <-chan inputLine {
+	lines := make(chan inputLine)
+	done := make(chan struct{})
+	for _, source := range sources {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			r, err := source.open()
+			if err != nil {
+				lines <- inputLine{source: source.name, err: err}
+				return
+			}
+			defer r.Close()
+
+			scanner := bufio.NewScanner(r)
+			num := 0
+			for scanner.Scan() {
+				num++
+				// The scanner reuses its buffer once the line is handed over
+				text := append([]byte(nil), scanner.Bytes()...)
+				lines <- inputLine{source: source.name, num: num, text: text}
+			}
+			if err := scanner.Err(); err != nil {
+				lines <- inputLine{source: source.name, num: num, err: err}
+			}
+		}()
+	}
+	go func() {
+		for range sources {
+			<-done
+		}
+		close(lines)
+	}()
+	return lines
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func stringSource(name, data string) inputSource {
+	return inputSource{
+		name: name,
+		open: func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(data)), nil },
+	}
+}
+
+func TestReadInputs(t *testing.T) {
+	sources := []inputSource{
+		stringSource("first", "a1\na2\n\na4"),
+		stringSource("second", "b1\nb2\n"),
+		{name: "broken", open: func() (io.ReadCloser, error) { return nil, errors.New("no such pipe") }},
+	}
+
+	got := map[string][]string{}
+	var failed []string
+	for in := range readInputs(sources) {
+		if in.err != nil {
+			failed = append(failed, in.source+": "+in.err.Error())
+			continue
+		}
+		// Lines of each source keep their order and are numbered from 1
+		if in.num != len(got[in.source])+1 {
+			t.Errorf("Expected line %d of %s, got line %d", len(got[in.source])+1, in.source, in.num)
+		}
+		got[in.source] = append(got[in.source], string(in.text))
+	}
+
+	if expected := []string{"a1", "a2", "", "a4"}; !slices.Equal(got["first"], expected) {
+		t.Errorf("Expected %q from first, got %q", expected, got["first"])
+	}
+	if expected := []string{"b1", "b2"}; !slices.Equal(got["second"], expected) {
+		t.Errorf("Expected %q from second, got %q", expected, got["second"])
+	}
+	if expected := []string{"broken: no such pipe"}; !slices.Equal(failed, expected) {
+		t.Errorf("Expected errors %q, got %q", expected, failed)
+	}
+}
+
+func TestParallelInputsNamedPipes(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing")
+	if err := os.WriteFile(existing, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	sources, err := parallelInputs([]string{existing, missing}, nil)
+	if err != nil {
+		t.Skipf("Named pipes are not available: %v", err)
+	}
+	info, err := os.Stat(missing)
+	if err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("Expected a named pipe at %s, got %v, %v", missing, info, err)
+	}
+
+	removeCreated(sources)
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("Expected the named pipe to be removed, got %v", err)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Errorf("Expected the existing input to be kept, got %v", err)
+	}
+}
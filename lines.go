@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// prescanMemoryLimit is how much input --prescan keeps in memory before it
+// spills the rest to a temporary file. Tests lower it.
+var prescanMemoryLimit = 32 << 20
+
+// runLines copies stdin to stdout unchanged while advancing bar once per
+// line. With prescan, all of stdin is buffered first to learn the total.
+func runLines(bar *pbar.Bar, prescan bool) int {
+	ticker := startStreamTicker(bar, defaultTickInterval)
+
+	var src io.Reader = os.Stdin
+	if prescan {
+		// Count the input behind a spinner, then replay it against a real total
+		var style, message string
+		ticker.Update(func(b *pbar.Bar) {
+			style, message = b.Style, b.Message
+			b.Style = "spinner"
+			b.Message = "Counting lines..."
+		})
+		replay, lines, err := bufferInput(os.Stdin, func(n int64) {
+			ticker.Update(func(b *pbar.Bar) {
+				b.Current += n
+			})
+		})
+		if err != nil {
+			return stopStreamTicker(ticker, err)
+		}
+		defer replay.Close()
+		ticker.Update(func(b *pbar.Bar) {
+			b.Style, b.Message = style, message
+			b.Total = lines
+			b.Current = 0
+			b.PreviousCurrent = 0
+			b.ThroughputHistory = nil
+			b.LastUpdateTime = time.Time{}
+			b.StartTime = time.Now()
+		})
+		src = replay
+	}
+
+	counter := &lineCounter{}
+	err := copyCounting(os.Stdout, src, func(p []byte) {
+		n := counter.count(p)
+		ticker.Update(func(b *pbar.Bar) {
+			b.Current += n
+		})
+	})
+	if n := counter.finish(); n > 0 {
+		ticker.Update(func(b *pbar.Bar) {
+			b.Current += n
+		})
+	}
+	return stopStreamTicker(ticker, err)
+}
+
+// lineCounter counts lines across chunk boundaries, including a final line
+// without a trailing newline.
+type lineCounter struct {
+	partial bool // The last chunk ended in the middle of a line
+}
+
+func (c *lineCounter) count(p []byte) int64 {
+	if len(p) == 0 {
+		return 0
+	}
+	c.partial = p[len(p)-1] != '\n'
+	return int64(bytes.Count(p, []byte{'\n'}))
+}
+
+func (c *lineCounter) finish() int64 {
+	if c.partial {
+		c.partial = false
+		return 1
+	}
+	return 0
+}
+
+// bufferInput reads all of r, counting lines as it goes, and returns a reader
+// that replays it. Input beyond prescanMemoryLimit is spilled to a temporary
+// file which is removed when the replay reader is closed.
+func bufferInput(r io.Reader, counted func(n int64)) (io.ReadCloser, int64, error) {
+	var mem bytes.Buffer
+	var spill *os.File
+	var lines int64
+	counter := &lineCounter{}
+
+	buf := make([]byte, pipeBufferSize)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			if spill == nil && mem.Len()+n > prescanMemoryLimit {
+				f, err := os.CreateTemp("", "pbar-prescan-*")
+				if err != nil {
+					return nil, 0, fmt.Errorf("prescan: %w", err)
+				}
+				spill = f
+				if _, err := spill.Write(mem.Bytes()); err != nil {
+					closeSpill(spill)
+					return nil, 0, fmt.Errorf("prescan: %w", err)
+				}
+				mem.Reset()
+			}
+			if spill != nil {
+				if _, err := spill.Write(chunk); err != nil {
+					closeSpill(spill)
+					return nil, 0, fmt.Errorf("prescan: %w", err)
+				}
+			} else {
+				mem.Write(chunk)
+			}
+			c := counter.count(chunk)
+			lines += c
+			counted(c)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if spill != nil {
+				closeSpill(spill)
+			}
+			return nil, 0, fmt.Errorf("read: %w", readErr)
+		}
+	}
+	lines += counter.finish()
+
+	if spill == nil {
+		return io.NopCloser(&mem), lines, nil
+	}
+	if _, err := spill.Seek(0, io.SeekStart); err != nil {
+		closeSpill(spill)
+		return nil, 0, fmt.Errorf("prescan: %w", err)
+	}
+	return &spillFile{spill}, lines, nil
+}
+
+// spillFile removes its temporary file when closed.
+type spillFile struct {
+	*os.File
+}
+
+func (f *spillFile) Close() error {
+	return closeSpill(f.File)
+}
+
+func closeSpill(f *os.File) error {
+	err := f.Close()
+	os.Remove(f.Name())
+	return err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineCounter(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected int64
+	}{
+		{"no input", nil, 0},
+		{"one line per chunk", []string{"a\n", "b\n"}, 2},
+		{"newline at the start of a chunk", []string{"a", "\nb\n"}, 2},
+		{"newline alone in a chunk", []string{"a", "\n", "b\n"}, 2},
+		{"line split across chunks", []string{"ab", "cd", "e\n"}, 1},
+		{"last line without a newline", []string{"a\nb"}, 2},
+		{"last line without a newline after a chunk boundary", []string{"a\n", "b"}, 2},
+		{"empty chunk after a partial line", []string{"a", ""}, 1},
+		{"blank lines", []string{"\n\n", "\n"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &lineCounter{}
+			var lines int64
+			for _, chunk := range tt.chunks {
+				lines += counter.count([]byte(chunk))
+			}
+			lines += counter.finish()
+			if lines != tt.expected {
+				t.Errorf("Expected %d lines, got %d", tt.expected, lines)
+			}
+		})
+	}
+}
+
+func TestBufferInput(t *testing.T) {
+	var input bytes.Buffer
+	for i := range 300 {
+		fmt.Fprintf(&input, "line %d\n", i)
+	}
+	input.WriteString("no newline")
+
+	tests := []struct {
+		name   string
+		limit  int
+		spills bool
+	}{
+		{"kept in memory", input.Len() + 1, false},
+		{"spilled to a temporary file", 1000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+			defer func(limit int) { prescanMemoryLimit = limit }(prescanMemoryLimit)
+			prescanMemoryLimit = tt.limit
+
+			var counted int64
+			// Reading a byte at a time crosses the limit partway through the input
+			replay, lines, err := bufferInput(iotest.OneByteReader(bytes.NewReader(input.Bytes())), func(n int64) {
+				counted += n
+			})
+			if err != nil {
+				t.Fatalf("bufferInput failed: %v", err)
+			}
+			if lines != 301 {
+				t.Errorf("Expected 301 lines, got %d", lines)
+			}
+			if counted != 300 {
+				t.Errorf("Expected 300 complete lines reported while reading, got %d", counted)
+			}
+			if files, _ := os.ReadDir(dir); (len(files) > 0) != tt.spills {
+				t.Errorf("Expected spill=%v, found %d temporary files", tt.spills, len(files))
+			}
+
+			replayed, err := io.ReadAll(replay)
+			if err != nil {
+				t.Fatalf("Replay failed: %v", err)
+			}
+			if !bytes.Equal(replayed, input.Bytes()) {
+				t.Errorf("Replayed input differs: got %d bytes, expected %d", len(replayed), input.Len())
+			}
+			if err := replay.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+			if files, _ := os.ReadDir(dir); len(files) > 0 {
+				t.Errorf("Expected the temporary file to be removed, found %v", files[0].Name())
+			}
+		})
+	}
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 
@@ -51,7 +52,8 @@ func generateInstanceID(explicitID string) string {
 
 	var signatureParts []string
 	flag.CommandLine.Visit(func(flag *flag.Flag) {
-		if flag.Name != "current" && flag.Name != "total" && flag.Name != "message" {
+		if flag.Name != "current" && flag.Name != "total" && flag.Name != "message" &&
+			flag.Name != "inc" && flag.Name != "fail" && flag.Name != "fail-exit-code" {
 			signatureParts = append(signatureParts, "--"+flag.Name, flag.Value.String())
 		}
 	})
@@ -68,34 +70,108 @@ func generateInstanceID(explicitID string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// checkFailExitCode rejects the exit codes that would not tell the calling
+// shell that the bar failed.
+func checkFailExitCode(code int) error {
+	if code <= 0 || code > 255 {
+		return fmt.Errorf("invalid --fail-exit-code %d. Must be between 1 and 255", code)
+	}
+	return nil
+}
+
+// failBar marks bar as failed, draws it on w and deletes its saved state, so
+// that the next update of the instance starts a new bar.
+func failBar(w io.Writer, bar *pbar.Bar, instanceID, reason string) {
+	bar.Finished = false
+	bar.Fail(reason)
+	fmt.Fprintln(w, bar.Render())
+	pbar.DeleteState(instanceID)
+}
+
 func main() {
+	// Subcommands have their own flag sets and are dispatched before the
+	// global flags are parsed
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "spin":
+			os.Exit(runSpin(os.Args[2:]))
+		case "exec":
+			os.Exit(runExec(os.Args[2:]))
+		case "watch-file":
+			os.Exit(runWatchFile(os.Args[2:]))
+		case "watch-dir":
+			os.Exit(runWatchDir(os.Args[2:]))
+		case "tail":
+			os.Exit(runTail(os.Args[2:]))
+		case "timer":
+			os.Exit(runTimer(os.Args[2:]))
+		case "daemon":
+			os.Exit(runDaemon(os.Args[2:]))
+		case "attach":
+			os.Exit(runAttach(os.Args[2:]))
+		}
+	}
+
 	// Declare variables for flags
-	var width int
+	var widthSpec string
 	var style string
 	var colorBarName string
 	var colorTextName string
 	var finishedMessage string
 	var version bool
 	var customChars string
+	var lineFormat string
 	var parallel bool
+	var inputFormat string
+	var inputPaths []string
+	var inputFDs []int
 	var message string // Declare message flag
 	var showElapsed, showThroughput, showETA bool
 	var explicitInstanceID string // New flag for explicit ID
+	var inc int64
+	var totalFlag int64
+	var failReason string
+	var failExitCode int
+	var pipe bool
+	var sizeSpec string
+	var countLines bool
+	var prescan bool
+	var pid, fd int
+	var socket string
+	var listen string
+	var metricsPath string
 
 	// Define flags
-	flag.IntVar(&width, "width", defaultWidth, "Width of the progress bar")
+	flag.StringVar(&widthSpec, "width", strconv.Itoa(defaultWidth), widthUsage)
 	flag.StringVar(&style, "style", defaultStyle, "Style of the progress bar (classic, block, spinner, arrow, braille, custom, braille-spinner)")
 	flag.StringVar(&colorBarName, "colorbar", "", fmt.Sprintf("Color for the bar. Available: %s", pbar.GetAvailableColors()))
 	flag.StringVar(&colorTextName, "colortext", "", fmt.Sprintf("Color for the text. Available: %s", pbar.GetAvailableColors()))
 	flag.StringVar(&finishedMessage, "finished-message", "", "Message to display when the progress bar is complete")
 	flag.BoolVar(&version, "version", false, "Print version information")
-	flag.StringVar(&customChars, "chars", "", "Custom characters for the progress bar (e.g., '#=')")
+	flag.StringVar(&customChars, "chars", "", "Custom fill, empty and optional head characters for the progress bar (e.g., '#=' or '=->')")
+	flag.StringVar(&lineFormat, "format", "", formatUsage)
 	flag.BoolVar(&parallel, "parallel", false, "Enable parallel progress bar rendering")
+	flag.StringArrayVar(&inputPaths, "input", nil, "In parallel mode, read updates from this file or named pipe (created if missing) instead of stdin. Can be repeated")
+	flag.IntSliceVar(&inputFDs, "input-fd", nil, "In parallel mode, read updates from this open file descriptor instead of stdin. Can be repeated")
+	flag.StringVar(&listen, "listen", "", "In parallel mode, serve the bars over HTTP on this address (e.g. 127.0.0.1:9099): JSON at /bars, Server-Sent Events at /events, Prometheus metrics at /metrics")
+	flag.StringVar(&metricsPath, "metrics-file", "", "In parallel mode, rewrite this file with the bars in the Prometheus text format after each render")
+	flag.StringVar(&inputFormat, "input-format", "json", "Format of the updates read in parallel mode: json, or text ('id current total [message]' or 'id key=value ...')")
 	flag.StringVar(&message, "message", "", "Optional message to display alongside the progress bar")
 	flag.BoolVar(&showElapsed, "show-elapsed", true, "Show elapsed time (default: true)")
 	flag.BoolVar(&showThroughput, "show-throughput", true, "Show throughput (iterations/second) (default: true)")
 	flag.BoolVar(&showETA, "show-eta", true, "Show estimated time remaining (default: true)")
 	flag.StringVar(&explicitInstanceID, "id", "", "Unique ID for the progress bar instance (optional)")
+	flag.StringVar(&socket, "socket", "", "Send the update to the 'pbar daemon' listening on this Unix socket instead of drawing the bar")
+	flag.Int64Var(&inc, "inc", 0, "Add N to the saved current value instead of passing current and total")
+	flag.Int64Var(&totalFlag, "total", defaultTotal, "Total to use when current and total are not given as positional arguments")
+	flag.StringVar(&failReason, "fail", "", "Mark the saved bar as failed with the given reason, then exit with --fail-exit-code")
+	flag.IntVar(&failExitCode, "fail-exit-code", 1, "Exit code used by --fail, from 1 to 255")
+	flag.BoolVar(&pipe, "pipe", false, "Copy stdin to stdout and draw the bar on stderr from the bytes copied")
+	flag.StringVar(&sizeSpec, "size", "", "Expected number of bytes in pipe mode (e.g. 4G). Without it, a spinner shows the throughput")
+	flag.BoolVar(&countLines, "lines", false, "Copy stdin to stdout and advance the bar once per line (use --total for the expected count)")
+	flag.BoolVar(&prescan, "prescan", false, "With --lines, buffer all of stdin first to learn the total")
+	flag.IntVar(&pid, "pid", 0, "Track how far a running process has read through a file (Linux only)")
+	flag.IntVar(&fd, "fd", -1, "With --pid, the descriptor to track (default: the largest open regular file)")
 
 	flag.Parse()
 
@@ -108,9 +184,34 @@ func main() {
 		os.Exit(0)
 	}
 
+	if lineFormat != "" {
+		if _, err := pbar.ParseFormat(lineFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid --format: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// If parallel mode is enabled
 	if parallel {
+		if inputFormat != "json" && inputFormat != "text" {
+			fmt.Fprintf(os.Stderr, "Error: Invalid --input-format '%s'. Must be one of: json, text\n", inputFormat)
+			os.Exit(1)
+		}
+		sources, err := parallelInputs(inputPaths, inputFDs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		manager := pbar.NewManager()
+		manager.LineWidth = lineWidthOf(os.Stdout)
+		metrics := newMetricsFile(metricsPath)
+		if listen != "" {
+			if err := serveStatus(listen, manager); err != nil {
+				removeCreated(sources)
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
 		// Hide cursor
 		fmt.Print("\033[?25l")
@@ -121,19 +222,42 @@ func main() {
 		go func() {
 			<-c
 			manager.Clear()
+			removeCreated(sources)
 			fmt.Print("\033[?25h") // Show cursor
 			os.Exit(0)
 		}()
 
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			line := scanner.Bytes()
+		// Errors name the input they came from when there are several
+		where := func(in inputLine) string {
+			if len(sources) == 1 {
+				return fmt.Sprintf("line %d", in.num)
+			}
+			return fmt.Sprintf("line %d of %s", in.num, in.source)
+		}
+		for in := range readInputs(sources) {
+			if in.err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from %s: %v\n", in.source, in.err)
+				continue
+			}
 			var update pbar.Update
-			err := json.Unmarshal(line, &update)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error parsing JSON: %v\n", err)
+			if inputFormat == "text" {
+				if len(bytes.TrimSpace(in.text)) == 0 {
+					continue
+				}
+				var err error
+				if update, err = pbar.ParseTextUpdate(string(in.text)); err != nil {
+					manager.RecordParseError()
+					fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", where(in), err)
+					continue
+				}
+			} else if err := json.Unmarshal(in.text, &update); err != nil {
+				manager.RecordParseError()
+				fmt.Fprintf(os.Stderr, "Error parsing JSON on %s: %v\n", where(in), err)
 				continue
 			}
+			if update.Format == "" {
+				update.Format = lineFormat
+			}
 			if update.ShowElapsed == nil {
 				update.ShowElapsed = boolPtr(showElapsed)
 			}
@@ -143,31 +267,117 @@ func main() {
 			if update.ShowETA == nil {
 				update.ShowETA = boolPtr(showETA)
 			}
-			manager.UpdateBar(update)
+			if err := manager.UpdateBar(update); err != nil {
+				fmt.Fprintf(os.Stderr, "Error applying update on %s: %v\n", where(in), err)
+				continue
+			}
 			manager.RenderAll()
+			metrics.write(manager)
 		}
-
-		if err := scanner.Err(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading from stdin: %v\n", err)
-		}
+		metrics.write(manager) // Counts parse errors after the last render
+		removeCreated(sources)
 
 		manager.Clear()
 		fmt.Print("\033[?25h") // Show cursor
 		return
 	}
 
+	// If pipe, line-counting or process tracking mode is enabled
+	pidMode := flag.CommandLine.Changed("pid")
+	if pipe || countLines || pidMode {
+		if (pipe && countLines) || (pipe && pidMode) || (countLines && pidMode) {
+			fmt.Fprintf(os.Stderr, "Error: Only one of --pipe, --lines and --pid can be used\n")
+			os.Exit(1)
+		}
+		if prescan && !countLines {
+			fmt.Fprintf(os.Stderr, "Error: --prescan requires --lines\n")
+			os.Exit(1)
+		}
+		if !isValidStyle(style) {
+			fmt.Fprintf(os.Stderr, "Error: Invalid style '%s'. Must be one of: classic, block, spinner, arrow, braille, custom, braille-spinner\n", style)
+			os.Exit(1)
+		}
+		width, lineWidth, auto, err := barWidth(widthSpec, os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		bar := &pbar.Bar{
+			Width:             width,
+			LineWidth:         lineWidth,
+			AutoWidth:         auto,
+			Style:             style,
+			ColorBar:          pbar.GetColorCode(colorBarName),
+			ColorText:         pbar.GetColorCode(colorTextName),
+			CustomChars:       customChars,
+			Format:            lineFormat,
+			Message:           message,
+			CompletionMessage: finishedMessage,
+			ShowElapsed:       showElapsed,
+			ShowThroughput:    showThroughput,
+			ShowETA:           showETA,
+			StartTime:         time.Now(),
+		}
+		switch {
+		case pidMode:
+			bar.Unit = pbar.UnitBytes
+			os.Exit(runPid(bar, pid, fd))
+		case pipe:
+			bar.Unit = pbar.UnitBytes
+			if sizeSpec != "" {
+				size, err := pbar.ParseSize(sizeSpec)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: Invalid --size: %v\n", err)
+					os.Exit(1)
+				}
+				bar.Total = size
+			}
+		case countLines:
+			bar.Unit = "lines"
+			if flag.CommandLine.Changed("total") {
+				bar.Total = totalFlag
+			}
+		}
+		if bar.Total <= 0 && !prescan && style != "spinner" && style != "braille-spinner" {
+			bar.Style = "spinner" // Without a total there is no percentage to show
+		}
+
+		if pipe {
+			os.Exit(runPipe(bar))
+		}
+		os.Exit(runLines(bar, prescan))
+	}
+
 	// --- Single bar mode (existing logic) ---
 
+	// Relative increments load the saved bar and add to it
+	incMode := flag.CommandLine.Changed("inc")
+	if incMode && len(positionalArgs) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: --inc cannot be combined with positional current and total values. Use --total to set the total.\n")
+		os.Exit(1)
+	}
+
+	// A failed bar keeps the saved progress unless values are given explicitly
+	failMode := flag.CommandLine.Changed("fail")
+	if failMode && incMode {
+		fmt.Fprintf(os.Stderr, "Error: --fail cannot be combined with --inc.\n")
+		os.Exit(1)
+	}
+	if err := checkFailExitCode(failExitCode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Handle positional arguments for current and total
-	var current, total int
+	var current, total int64
 	if len(positionalArgs) == 2 {
 		var err error
-		current, err = strconv.Atoi(positionalArgs[0])
+		current, err = strconv.ParseInt(positionalArgs[0], 10, 64)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Invalid current value '%s'. Must be an integer.\n", positionalArgs[0])
 			os.Exit(1)
 		}
-		total, err = strconv.Atoi(positionalArgs[1])
+		total, err = strconv.ParseInt(positionalArgs[1], 10, 64)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Invalid total value '%s'. Must be an integer.\n", positionalArgs[1])
 			os.Exit(1)
@@ -175,7 +385,7 @@ func main() {
 	} else if len(positionalArgs) == 0 {
 		// Default values if no positional arguments are provided
 		current = 0
-		total = defaultTotal
+		total = totalFlag
 	} else if len(positionalArgs) == 1 {
 		fmt.Fprintf(os.Stderr, "Error: When using positional arguments, provide both current and total values. Got only: %s\n", positionalArgs[0])
 		os.Exit(1)
@@ -190,19 +400,81 @@ func main() {
 		os.Exit(1)
 	}
 
+	width, lineWidth, autoWidth, err := barWidth(widthSpec, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Validate and get ANSI color codes
 	colorBarCode := pbar.GetColorCode(colorBarName)
 	colorTextCode := pbar.GetColorCode(colorTextName)
 
 	instanceID := generateInstanceID(explicitInstanceID)
 
+	// A daemon keeps the bar itself, so only the changes are sent to it
+	if socket != "" {
+		update := pbar.Update{ID: instanceID}
+		switch {
+		case failMode:
+			update.Op = pbar.OpFail
+			update.Message = &failReason
+		case incMode:
+			update.Delta = inc
+		}
+		if len(positionalArgs) == 2 {
+			update.Current = &current
+			update.Total = &total
+		} else if flag.CommandLine.Changed("total") {
+			update.Total = &totalFlag
+		}
+		if flag.CommandLine.Changed("message") && !failMode {
+			update.Message = &message
+		}
+		if flag.CommandLine.Changed("width") && !autoWidth {
+			update.Width = width
+		}
+		if flag.CommandLine.Changed("style") {
+			update.Style = style
+		}
+		update.ColorBar = colorBarName
+		update.ColorText = colorTextName
+		update.CustomChars = customChars
+		update.Format = lineFormat
+		if flag.CommandLine.Changed("show-elapsed") {
+			update.ShowElapsed = boolPtr(showElapsed)
+		}
+		if flag.CommandLine.Changed("show-throughput") {
+			update.ShowThroughput = boolPtr(showThroughput)
+		}
+		if flag.CommandLine.Changed("show-eta") {
+			update.ShowETA = boolPtr(showETA)
+		}
+		if err := pbar.SendUpdates(socket, update); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not update the daemon on %s: %v\n", socket, err)
+			os.Exit(1)
+		}
+		if failMode {
+			os.Exit(failExitCode)
+		}
+		return
+	}
+
+	// Hold the state lock across the whole load/modify/save cycle so that
+	// concurrent invocations for the same instance do not clobber each other.
+	lock, err := pbar.LockState(instanceID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not lock state for instance '%s': %v\n", instanceID, err)
+	}
+
 	var bar *pbar.Bar
-	if current > 0 {
+	if current > 0 || incMode || failMode {
 		loadedBar, err := pbar.LoadState(instanceID)
 		if err == nil {
 			bar = loadedBar
 		}
 	}
+	loaded := bar != nil
 
 	if bar == nil {
 		// If state was not loaded or current is 0, create a new bar
@@ -211,21 +483,45 @@ func main() {
 		pbar.DeleteState(instanceID) // Ensure no old state interferes
 	}
 
+	if incMode {
+		current = bar.Current + inc
+		if loaded && !flag.CommandLine.Changed("total") {
+			total = bar.Total
+		}
+	}
+	if failMode && loaded && len(positionalArgs) == 0 {
+		current = bar.Current
+		if !flag.CommandLine.Changed("total") {
+			total = bar.Total
+		}
+	}
+
 	bar.Total = total
 	bar.PreviousCurrent = bar.Current
 	bar.Current = current
 	bar.Width = width
+	bar.LineWidth = lineWidth
+	bar.AutoWidth = autoWidth
 	bar.Style = style
 	bar.ColorBar = colorBarCode
 	bar.ColorText = colorTextCode
 	bar.Finished = current >= total
 	bar.CustomChars = customChars
+	bar.Format = lineFormat
 	bar.Message = message
 	bar.CompletionMessage = finishedMessage
 	bar.ShowElapsed = showElapsed
 	bar.ShowThroughput = showThroughput
 	bar.ShowETA = showETA
 
+	if failMode {
+		failBar(os.Stdout, bar, instanceID, failReason)
+		if lock != nil {
+			lock.Unlock()
+		}
+		os.Exit(failExitCode)
+	}
+
 	fmt.Print(bar.Render())
 
 	if bar.Finished {
@@ -234,15 +530,14 @@ func main() {
 		pbar.SaveState(bar, instanceID)
 	}
 
+	if lock != nil {
+		lock.Unlock()
+	}
+
 	// Exit with an error code if current > total (unless finished)
 	isIndeterminate := style == "spinner" || style == "braille-spinner"
 	if !(current >= total) && !isIndeterminate && current > total {
 		fmt.Fprintln(os.Stderr, "Error: Current value cannot be greater than total.")
 		os.Exit(1)
 	}
-
-	if width <= 0 {
-		fmt.Fprintf(os.Stderr, "Error: Width must be positive\n")
-		os.Exit(1)
-	}
 }
\ No newline at end of file
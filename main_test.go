@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+func TestCheckFailExitCode(t *testing.T) {
+	tests := []struct {
+		code  int
+		valid bool
+	}{
+		{-1, false},
+		{0, false},
+		{1, true},
+		{3, true},
+		{255, true},
+		{256, false},
+	}
+	for _, tt := range tests {
+		err := checkFailExitCode(tt.code)
+		if (err == nil) != tt.valid {
+			t.Errorf("checkFailExitCode(%d) returned %v, expected valid=%v", tt.code, err, tt.valid)
+		}
+	}
+}
+
+func TestFailBar(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir()) // Where the state files are kept
+	const id = "fail_test"
+	if err := pbar.SaveState(&pbar.Bar{Total: 100, Current: 40, Width: 10}, id); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	bar, err := pbar.LoadState(id)
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	failBar(&out, bar, id, "disk full")
+	expected := "\r\x1b[31m[✘]\x1b[0m 40% disk full\x1b[K\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	if _, err := pbar.LoadState(id); err == nil {
+		t.Errorf("Expected the saved state to be deleted")
+	}
+}
@@ -44,3 +44,14 @@ func GetAvailableColors() string {
 	}
 	return strings.Join(colors, ", ")
 }
+
+// colorName returns the name of the color whose ANSI escape code is code, or
+// an empty string if there is none.
+func colorName(code string) string {
+	for name, c := range AnsiColors {
+		if c == code && name != "reset" {
+			return name
+		}
+	}
+	return ""
+}
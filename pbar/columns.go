@@ -0,0 +1,355 @@
+package pbar
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+)
+
+// Snapshot is a read-only view of a bar at the moment it is rendered. Each
+// Column of the bar draws its part of the line from it.
+type Snapshot struct {
+	Current     int64
+	Total       int64
+	Percent     float64 // Fraction done, from 0 to 1
+	Unit        string
+	Style       string
+	CustomChars string
+	ColorBar    string // ANSI escape code
+	ColorText   string // ANSI escape code
+	Width       int    // Cells the bar may use
+	Message     string
+	Status      string // Completion message of a finished bar, or failure reason of a failed one
+	Finished    bool
+	Failed      bool
+	Paused      bool
+	Frame       int // Spinner frame to draw
+
+	Started     bool          // The bar has a start time, so Elapsed is meaningful
+	Elapsed     time.Duration // Excluding paused periods
+	RateKnown   bool          // Rate and the ETA can be shown: the bar is running, and is not a spinner without a unit
+	Rate        float64       // Average throughput in units per second
+	HasDeadline bool
+	Remaining   time.Duration // Time left until the deadline, if HasDeadline
+
+	ShowElapsed    bool
+	ShowThroughput bool
+	ShowETA        bool
+}
+
+// Indeterminate reports whether the bar is a spinner, with no percentage.
+func (s Snapshot) Indeterminate() bool {
+	return s.Style == "spinner" || s.Style == "braille-spinner"
+}
+
+// FormatRate formats a throughput in the bar's unit.
+func (s Snapshot) FormatRate(perSecond float64) string {
+	switch s.Unit {
+	case "":
+		return fmt.Sprintf("%.2f it/s", perSecond)
+	case UnitBytes:
+		return FormatBytes(perSecond) + "/s"
+	default:
+		return fmt.Sprintf("%.2f %s/s", perSecond, s.Unit)
+	}
+}
+
+// FormatAmount formats a count in the bar's unit.
+func (s Snapshot) FormatAmount(amount float64) string {
+	switch s.Unit {
+	case "":
+		return fmt.Sprintf("%.0f", amount)
+	case UnitBytes:
+		return FormatBytes(amount)
+	default:
+		return fmt.Sprintf("%.0f %s", amount, s.Unit)
+	}
+}
+
+// Column draws one part of a bar's line. A column with nothing to show
+// returns an empty string, and the space before it is left out too.
+type Column interface {
+	Render(s Snapshot) string
+}
+
+// ColumnFunc adapts an ordinary function to a Column.
+type ColumnFunc func(s Snapshot) string
+
+func (f ColumnFunc) Render(s Snapshot) string {
+	return f(s)
+}
+
+// dropper is implemented by the columns that Bar.LineWidth may drop, lowest
+// rank first, when the line is too long.
+type dropper interface {
+	dropRank() int
+}
+
+// DefaultColumns returns the columns of a bar with neither Columns nor a
+// Format, the same as DefaultFormat.
+func DefaultColumns() []Column {
+	return []Column{
+		BarColumn{},
+		TextColumn{Text: " "},
+		PercentColumn{},
+		TextColumn{Text: " "},
+		StatusColumn{},
+		TextColumn{Text: " "},
+		AmountColumn{},
+		TextColumn{Text: " "},
+		ElapsedColumn{},
+		TextColumn{Text: " "},
+		RateColumn{},
+		TextColumn{Text: " "},
+		ETAColumn{},
+		TextColumn{Text: " "},
+		PausedColumn{},
+		TextColumn{Text: " "},
+		MessageColumn{},
+	}
+}
+
+// renderColumns draws the line, leaving out the columns whose drop rank is
+// at most dropped.
+func renderColumns(columns []Column, s Snapshot, dropped int) string {
+	var line []byte
+	afterText := false
+	for _, c := range columns {
+		var text string
+		if d, ok := c.(dropper); !ok || d.dropRank() == 0 || d.dropRank() > dropped {
+			text = c.Render(s)
+		}
+		if text == "" && afterText && len(line) > 0 && line[len(line)-1] == ' ' {
+			line = line[:len(line)-1]
+		}
+		line = append(line, text...)
+		_, afterText = c.(TextColumn)
+	}
+	return string(line)
+}
+
+// TextColumn shows fixed text, such as the separators between columns.
+type TextColumn struct {
+	Text  string
+	Color string // ANSI escape code
+}
+
+func (c TextColumn) Render(Snapshot) string {
+	return colorize(c.Text, c.Color)
+}
+
+// BarColumn shows the bar in the bar's style, a spinner for the spinner
+// styles, or the state icon of a finished or failed bar.
+type BarColumn struct {
+	Color string // Replaces the bar's color
+}
+
+func (c BarColumn) Render(s Snapshot) string {
+	switch {
+	case s.Failed:
+		return colorize("[✘]", cmp.Or(c.Color, AnsiColors["red"]))
+	case s.Finished:
+		return colorize("[✔]", c.Color)
+	case s.Indeterminate():
+		return SpinnerColumn(c).Render(s)
+	}
+	color := cmp.Or(c.Color, s.ColorBar)
+	switch s.Style {
+	case "block":
+		return renderBar(s.Width, s.Percent, "█", " ", "", color)
+	case "arrow":
+		return renderArrowBar(s.Width, s.Percent, color)
+	case "braille":
+		return renderBrailleBar(s.Width, s.Percent, color)
+	case "custom":
+		filledChar, emptyChar, headChar := customChars(s.CustomChars)
+		return renderBar(s.Width, s.Percent, filledChar, emptyChar, headChar, color)
+	}
+	return renderBar(s.Width, s.Percent, "#", "-", "", color)
+}
+
+// SpinnerColumn shows a spinner, with braille frames for the
+// braille-spinner style.
+type SpinnerColumn struct {
+	Color string // Replaces the bar's text color
+}
+
+func (c SpinnerColumn) Render(s Snapshot) string {
+	frames := spinnerChars
+	if s.Style == "braille-spinner" {
+		frames = brailleSpinnerChars
+	}
+	return "[" + colorize(frames[s.Frame%len(frames)], cmp.Or(c.Color, s.ColorText)) + "]"
+}
+
+// PercentColumn shows the percentage done, rounded down unless Decimals is
+// set. Spinners have none.
+type PercentColumn struct {
+	Decimals int
+	Color    string // Replaces the bar's text color
+}
+
+func (c PercentColumn) Render(s Snapshot) string {
+	if s.Indeterminate() && (s.Failed || !s.Finished) {
+		return ""
+	}
+	text := fmt.Sprintf("%d%%", int(s.Percent*100))
+	if c.Decimals > 0 {
+		text = fmt.Sprintf("%.*f%%", c.Decimals, s.Percent*100)
+	}
+	color := c.Color
+	if !s.Finished && !s.Failed {
+		color = cmp.Or(color, s.ColorText)
+	}
+	return colorize(text, color)
+}
+
+// StatusColumn shows the completion message of a finished bar or the
+// failure reason of a failed one.
+type StatusColumn struct {
+	Color string // ANSI escape code
+}
+
+func (c StatusColumn) Render(s Snapshot) string {
+	return colorize(s.Status, c.Color)
+}
+
+// CountColumn shows the current value in the bar's unit, or the total if
+// Total is set and the total is known.
+type CountColumn struct {
+	Total bool
+	Color string // ANSI escape code
+}
+
+func (c CountColumn) Render(s Snapshot) string {
+	if !c.Total {
+		return colorize(s.FormatAmount(float64(s.Current)), c.Color)
+	}
+	if s.Total <= 0 {
+		return ""
+	}
+	return colorize(s.FormatAmount(float64(s.Total)), c.Color)
+}
+
+// AmountColumn shows the amount counted so far by a spinner with a unit,
+// which has no percentage to show instead.
+type AmountColumn struct {
+	Color string // ANSI escape code
+}
+
+func (c AmountColumn) Render(s Snapshot) string {
+	if !s.Started || !s.Indeterminate() || s.Unit == "" {
+		return ""
+	}
+	return colorize(s.FormatAmount(float64(s.Current)), c.Color)
+}
+
+func (AmountColumn) dropRank() int { return dropAmount }
+
+// ElapsedColumn shows the elapsed time, unless ShowElapsed is off.
+type ElapsedColumn struct {
+	Color string // ANSI escape code
+}
+
+func (c ElapsedColumn) Render(s Snapshot) string {
+	if !s.Started || !s.ShowElapsed {
+		return ""
+	}
+	return colorize("Elapsed "+formatDuration(s.Elapsed), c.Color)
+}
+
+func (ElapsedColumn) dropRank() int { return dropElapsed }
+
+// RateColumn shows the average throughput, unless ShowThroughput is off.
+type RateColumn struct {
+	Color string // ANSI escape code
+}
+
+func (c RateColumn) Render(s Snapshot) string {
+	if !s.RateKnown || !s.ShowThroughput {
+		return ""
+	}
+	return colorize(s.FormatRate(s.Rate), c.Color)
+}
+
+func (RateColumn) dropRank() int { return dropRate }
+
+// ETAColumn shows the estimated time left, or the time left until the
+// deadline of a bar that has one, unless ShowETA is off.
+type ETAColumn struct {
+	Color string // ANSI escape code
+}
+
+func (c ETAColumn) Render(s Snapshot) string {
+	if !s.RateKnown || !s.ShowETA || s.Indeterminate() {
+		return ""
+	}
+	var text string
+	remainingItems := s.Total - s.Current
+	switch {
+	case s.HasDeadline:
+		// A fixed end time needs no estimate from the throughput
+		if s.Remaining > 0 && !s.Finished {
+			text = "Remaining " + formatDuration(s.Remaining)
+		} else {
+			text = "Remaining 0s"
+		}
+	case remainingItems <= 0:
+		text = "ETA 0s"
+	case s.Rate > 0:
+		eta := time.Duration(float64(remainingItems) / s.Rate * float64(time.Second))
+		text = "ETA " + formatDuration(eta)
+	default:
+		text = "ETA Inf"
+	}
+	return colorize(text, c.Color)
+}
+
+func (ETAColumn) dropRank() int { return dropETA }
+
+// PausedColumn shows "Paused" while the bar is paused.
+type PausedColumn struct {
+	Color string // ANSI escape code
+}
+
+func (c PausedColumn) Render(s Snapshot) string {
+	if !s.Started || !s.Paused {
+		return ""
+	}
+	return colorize("Paused", c.Color)
+}
+
+// MessageColumn shows the bar's message. When Bar.LineWidth is too narrow,
+// the message is shortened before the ETA is dropped.
+type MessageColumn struct {
+	Color string // ANSI escape code
+}
+
+func (c MessageColumn) Render(s Snapshot) string {
+	return colorize(s.Message, c.Color)
+}
+
+// PaddedColumn pads what Column shows to at least Width columns, aligned by
+// Align: '<' (the default), '>' or '^'. A positive MaxWidth shortens longer
+// text, marking the cut with "…".
+type PaddedColumn struct {
+	Column   Column
+	Width    int
+	Align    byte
+	MaxWidth int
+}
+
+func (c PaddedColumn) Render(s Snapshot) string {
+	text := c.Column.Render(s)
+	if c.MaxWidth > 0 {
+		text = ellipsize(text, c.MaxWidth)
+	}
+	return pad(text, c.Width, c.Align)
+}
+
+func (c PaddedColumn) dropRank() int {
+	if d, ok := c.Column.(dropper); ok {
+		return d.dropRank()
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package pbar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumns(t *testing.T) {
+	running := Snapshot{
+		Current:        25,
+		Total:          100,
+		Percent:        0.25,
+		Style:          "classic",
+		Width:          8,
+		Started:        true,
+		Elapsed:        90 * time.Second,
+		RateKnown:      true,
+		Rate:           2.5,
+		ShowElapsed:    true,
+		ShowThroughput: true,
+		ShowETA:        true,
+	}
+	with := func(change func(s *Snapshot)) Snapshot {
+		s := running
+		change(&s)
+		return s
+	}
+	green := AnsiColors["green"]
+	reset := AnsiColors["reset"]
+
+	tests := []struct {
+		name     string
+		column   Column
+		snapshot Snapshot
+		expected string
+	}{
+		{"text", TextColumn{Text: " | "}, running, " | "},
+		{"bar", BarColumn{}, running, "[##------]"},
+		{"bar color", BarColumn{Color: green}, with(func(s *Snapshot) { s.ColorBar = AnsiColors["red"] }), green + "[##------]" + reset},
+		{"bar style", BarColumn{}, with(func(s *Snapshot) { s.Style = "arrow" }), "[->      ]"},
+		{"bar custom chars", BarColumn{}, with(func(s *Snapshot) { s.Style, s.CustomChars = "custom", "=->" }), "[=>------]"},
+		{"bar failed", BarColumn{}, with(func(s *Snapshot) { s.Failed = true }), AnsiColors["red"] + "[✘]" + reset},
+		{"bar finished", BarColumn{}, with(func(s *Snapshot) { s.Finished = true }), "[✔]"},
+		{"bar spinner", BarColumn{}, with(func(s *Snapshot) { s.Style, s.Frame = "spinner", 1 }), "[/]"},
+		{"spinner", SpinnerColumn{}, with(func(s *Snapshot) { s.Frame = 6 }), "[-]"},
+		{"braille spinner", SpinnerColumn{Color: green}, with(func(s *Snapshot) { s.Style = "braille-spinner" }), "[" + green + "⠋" + reset + "]"},
+		{"percent", PercentColumn{}, with(func(s *Snapshot) { s.Percent = 0.256 }), "25%"},
+		{"percent decimals", PercentColumn{Decimals: 1}, with(func(s *Snapshot) { s.Percent = 0.256 }), "25.6%"},
+		{"percent text color", PercentColumn{}, with(func(s *Snapshot) { s.ColorText = green }), green + "25%" + reset},
+		{"percent spinner", PercentColumn{}, with(func(s *Snapshot) { s.Style = "spinner" }), ""},
+		{"status", StatusColumn{}, with(func(s *Snapshot) { s.Status = "done" }), "done"},
+		{"count", CountColumn{}, running, "25"},
+		{"count total", CountColumn{Total: true}, with(func(s *Snapshot) { s.Unit = UnitBytes; s.Total = 2048 }), "2.00 KiB"},
+		{"count unknown total", CountColumn{Total: true}, with(func(s *Snapshot) { s.Total = 0 }), ""},
+		{"amount", AmountColumn{}, with(func(s *Snapshot) { s.Style, s.Unit = "spinner", "lines" }), "25 lines"},
+		{"amount with a total", AmountColumn{}, running, ""},
+		{"elapsed", ElapsedColumn{}, running, "Elapsed 1m30s"},
+		{"elapsed hidden", ElapsedColumn{}, with(func(s *Snapshot) { s.ShowElapsed = false }), ""},
+		{"elapsed not started", ElapsedColumn{}, with(func(s *Snapshot) { s.Started = false }), ""},
+		{"rate", RateColumn{}, running, "2.50 it/s"},
+		{"rate unknown", RateColumn{}, with(func(s *Snapshot) { s.RateKnown = false }), ""},
+		{"eta", ETAColumn{}, running, "ETA 30s"},
+		{"eta stalled", ETAColumn{}, with(func(s *Snapshot) { s.Rate = 0 }), "ETA Inf"},
+		{"eta done", ETAColumn{}, with(func(s *Snapshot) { s.Current = 100 }), "ETA 0s"},
+		{"eta deadline", ETAColumn{}, with(func(s *Snapshot) { s.HasDeadline, s.Remaining = true, time.Minute }), "Remaining 1m0s"},
+		{"paused", PausedColumn{}, with(func(s *Snapshot) { s.Paused = true }), "Paused"},
+		{"message", MessageColumn{Color: green}, with(func(s *Snapshot) { s.Message = "ok" }), green + "ok" + reset},
+		{"padded", PaddedColumn{Column: PercentColumn{}, Width: 5, Align: '>'}, running, "  25%"},
+		{"shortened", PaddedColumn{Column: MessageColumn{Color: green}, Width: 6, MaxWidth: 6}, with(func(s *Snapshot) { s.Message = "extracting" }), green + "extra…" + reset},
+		{"func", ColumnFunc(func(s Snapshot) string { return fmt.Sprint(s.Total - s.Current) }), running, "75"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.column.Render(tt.snapshot); actual != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDefaultColumns(t *testing.T) {
+	if parsed := defaultFormat.Columns(); !reflect.DeepEqual(parsed, DefaultColumns()) {
+		t.Errorf("Expected DefaultFormat to compile to DefaultColumns, got %#v", parsed)
+	}
+
+	implicit, explicit := newLayoutBar(0), newLayoutBar(0)
+	explicit.Columns = DefaultColumns()
+	if expected, actual := implicit.Render(), explicit.Render(); actual != expected {
+		t.Errorf("Expected DefaultColumns to render\n%q\ngot\n%q", expected, actual)
+	}
+}
+
+func TestBarColumns(t *testing.T) {
+	left := ColumnFunc(func(s Snapshot) string {
+		return fmt.Sprintf("%d left", s.Total-s.Current)
+	})
+	bar := newLayoutBar(0)
+	bar.Width = 10
+	bar.Format = "{bar}" // Columns take precedence
+	bar.Columns = []Column{
+		PaddedColumn{Column: MessageColumn{}, Width: 14, MaxWidth: 14},
+		TextColumn{Text: " "},
+		BarColumn{},
+		TextColumn{Text: " "},
+		PercentColumn{Decimals: 1},
+		TextColumn{Text: " | "},
+		left,
+		TextColumn{Text: " "},
+		PaddedColumn{Column: ETAColumn{}, Width: 10, Align: '>'},
+	}
+	expected := "Downloading u… [#####-----] 50.0% | 50 left    ETA 25s"
+	if actual := visibleLine(bar.Render()); actual != expected {
+		t.Errorf("Expected\n'%s'\ngot\n'%s'", expected, actual)
+	}
+
+	// The padded ETA is still dropped first when the line is too long
+	bar.LineWidth = 50
+	if actual := visibleLine(bar.Render()); strings.Contains(actual, "ETA") || displayWidth(actual) > 50 {
+		t.Errorf("Expected the ETA to be dropped to fit 50 columns, got '%s'", actual)
+	}
+}
@@ -0,0 +1,220 @@
+package pbar
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultFormat is the layout of a bar whose Format is empty. Fields that
+// have nothing to show, such as the percentage of a spinner, are left out
+// together with the space before them.
+const DefaultFormat = "{bar} {percent} {status} {amount} {elapsed} {rate} {eta} {paused} {message}"
+
+// formatFields maps the fields a format can use to their columns.
+var formatFields = map[string]func(color string, precision int) Column{
+	"bar": func(color string, _ int) Column { return BarColumn{Color: color} },
+	"percent": func(color string, precision int) Column {
+		return PercentColumn{Decimals: max(precision, 0), Color: color}
+	},
+	"status":  func(color string, _ int) Column { return StatusColumn{Color: color} },
+	"amount":  func(color string, _ int) Column { return AmountColumn{Color: color} },
+	"current": func(color string, _ int) Column { return CountColumn{Color: color} },
+	"total":   func(color string, _ int) Column { return CountColumn{Total: true, Color: color} },
+	"elapsed": func(color string, _ int) Column { return ElapsedColumn{Color: color} },
+	"rate":    func(color string, _ int) Column { return RateColumn{Color: color} },
+	"eta":     func(color string, _ int) Column { return ETAColumn{Color: color} },
+	"paused":  func(color string, _ int) Column { return PausedColumn{Color: color} },
+	"message": func(color string, _ int) Column { return MessageColumn{Color: color} },
+}
+
+// textFields are the fields whose width is a fixed column: longer values
+// are shortened to fit rather than pushing the rest of the line along.
+var textFields = map[string]bool{"message": true, "status": true}
+
+// Format is a compiled line layout such as "{message:20} {bar} {percent}".
+// Each field compiles to one of the columns in columns.go, and the text
+// between fields to a TextColumn.
+//
+// A field is written {name}, {name:spec} or {name:spec|color}. The spec is
+// an optional alignment ('<' left, the default, '>' right or '^' centered),
+// a width the field is padded to, and a precision: the number of decimals
+// of {percent:.1f}, or the most columns any other field may take. The
+// message and status fields are also shortened to their width. The color
+// is one of the names in AnsiColors. "{{" and "}}" stand for literal braces.
+type Format struct {
+	source  string
+	columns []Column
+}
+
+// formatField is a parsed {name:spec|color} field.
+type formatField struct {
+	name      string
+	align     byte
+	width     int
+	precision int // -1 if not given
+	color     string
+}
+
+var defaultFormat = mustParseFormat(DefaultFormat)
+
+func mustParseFormat(template string) *Format {
+	f, err := ParseFormat(template)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// FormatFields returns the names of the fields a format can use.
+func FormatFields() []string {
+	names := make([]string, 0, len(formatFields))
+	for name := range formatFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFormat compiles a line template.
+func ParseFormat(template string) (*Format, error) {
+	f := &Format{source: template}
+	var literal strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '{' && strings.HasPrefix(template[i:], "{{"):
+			literal.WriteByte('{')
+			i++
+		case c == '}' && strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte('}')
+			i++
+		case c == '}':
+			return nil, fmt.Errorf("unexpected '}' at offset %d in format", i)
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '{' at offset %d in format", i)
+			}
+			field, err := parseFormatField(template[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			if literal.Len() > 0 {
+				f.columns = append(f.columns, TextColumn{Text: literal.String()})
+				literal.Reset()
+			}
+			f.columns = append(f.columns, field.column())
+			i += end
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	if literal.Len() > 0 {
+		f.columns = append(f.columns, TextColumn{Text: literal.String()})
+	}
+	return f, nil
+}
+
+// parseFormatField parses the inside of a {name:spec|color} field.
+func parseFormatField(s string) (formatField, error) {
+	part := formatField{align: '<', precision: -1}
+	s, color, hasColor := strings.Cut(s, "|")
+	name, spec, _ := strings.Cut(s, ":")
+	part.name = strings.TrimSpace(name)
+	if _, ok := formatFields[part.name]; !ok {
+		return part, fmt.Errorf("unknown field '%s' in format. Available: %s", part.name, strings.Join(FormatFields(), ", "))
+	}
+	if hasColor {
+		name := strings.ToLower(strings.TrimSpace(color))
+		code, ok := AnsiColors[name]
+		if !ok || name == "reset" {
+			return part, fmt.Errorf("unknown color '%s' for field '%s'. Available: %s", color, part.name, GetAvailableColors())
+		}
+		part.color = code
+	}
+	if err := part.parseSpec(spec); err != nil {
+		return part, fmt.Errorf("invalid spec '%s' for field '%s': %v", spec, part.name, err)
+	}
+	return part, nil
+}
+
+// parseSpec parses the [align][width][.precision] spec of a field.
+func (p *formatField) parseSpec(spec string) error {
+	if spec != "" && strings.IndexByte("<>^", spec[0]) >= 0 {
+		p.align = spec[0]
+		spec = spec[1:]
+	}
+	widthSpec, precisionSpec, hasPrecision := strings.Cut(spec, ".")
+	if widthSpec != "" {
+		width, err := strconv.Atoi(widthSpec)
+		if err != nil || width < 0 {
+			return errors.New("width must be a number")
+		}
+		p.width = width
+	}
+	if hasPrecision {
+		if p.name == "percent" {
+			precisionSpec = strings.TrimSuffix(precisionSpec, "f")
+		}
+		precision, err := strconv.Atoi(precisionSpec)
+		if err != nil || precision < 0 {
+			return errors.New("precision must be a number")
+		}
+		p.precision = precision
+	}
+	return nil
+}
+
+// column returns the column showing the field.
+func (p formatField) column() Column {
+	c := formatFields[p.name](p.color, p.precision)
+	maxWidth := 0
+	if p.precision > 0 && p.name != "percent" {
+		maxWidth = p.precision
+	}
+	if textFields[p.name] && p.width > 0 && (maxWidth == 0 || p.width < maxWidth) {
+		maxWidth = p.width
+	}
+	if p.width == 0 && maxWidth == 0 {
+		return c
+	}
+	return PaddedColumn{Column: c, Width: p.width, Align: p.align, MaxWidth: maxWidth}
+}
+
+// String returns the template the format was compiled from.
+func (f *Format) String() string {
+	return f.source
+}
+
+// Columns returns the columns the format lays out, which can be extended
+// and set as Bar.Columns.
+func (f *Format) Columns() []Column {
+	return slices.Clone(f.columns)
+}
+
+// colorize wraps text in the escape code of a color.
+func colorize(text, color string) string {
+	if text == "" || color == "" {
+		return text
+	}
+	return color + text + "\x1b[0m" // Use reset code directly
+}
+
+// pad aligns s in a column width columns wide.
+func pad(s string, width int, align byte) string {
+	gap := width - displayWidth(s)
+	if gap <= 0 {
+		return s
+	}
+	switch align {
+	case '>':
+		return strings.Repeat(" ", gap) + s
+	case '^':
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+	return s + strings.Repeat(" ", gap)
+}
@@ -0,0 +1,110 @@
+package pbar
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		message  string
+		expected string
+	}{
+		{"reordered", "{message:20} {bar} {current}/{total} {percent:.1f} {rate} {eta} {elapsed}", "Downloading ubuntu-24.04-desktop-amd64.iso",
+			"Downloading ubuntu-… [##########----------] 50/100 50.0% 2.00 it/s ETA 25s Elapsed 1m30s"},
+		{"padded message", "{message:8}|{percent}", "ok", "ok      |50%"},
+		{"wide message", "{message:6}|", "下载", "下载  |"},
+		{"right aligned", "{percent:>5}|", "", "  50%|"},
+		{"centered", "{percent:^6}|", "", " 50%  |"},
+		{"precision", "{percent:.2f} {message:.5}", "extracting", "50.00% extr…"},
+		{"colors", "{percent|green} {message|Yellow}", "ok", "\x1b[32m50%\x1b[0m \x1b[33mok\x1b[0m"},
+		{"empty fields", "{bar} {status} {paused} {message} {percent}", "", "[##########----------] 50%"},
+		{"braces", "{{{percent}}}", "", "{50%}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bar := newLayoutBar(0)
+			bar.Managed = true
+			bar.Format = tt.format
+			bar.Message = tt.message
+			if actual := bar.Render(); actual != tt.expected {
+				t.Errorf("Expected\n'%s'\ngot\n'%s'", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDefaultFormat(t *testing.T) {
+	states := map[string]func(b *Bar){
+		"running":  func(b *Bar) {},
+		"colored":  func(b *Bar) { b.ColorBar, b.ColorText = AnsiColors["green"], AnsiColors["cyan"] },
+		"spinner":  func(b *Bar) { b.Style, b.Unit = "spinner", UnitBytes },
+		"paused":   func(b *Bar) { b.Pause() },
+		"finished": func(b *Bar) { b.Finished = true },
+		"failed":   func(b *Bar) { b.Fail("disk full") },
+		"narrow":   func(b *Bar) { b.LineWidth = 40 },
+	}
+	for name, setup := range states {
+		t.Run(name, func(t *testing.T) {
+			implicit, explicit := newLayoutBar(0), newLayoutBar(0)
+			setup(implicit)
+			setup(explicit)
+			explicit.Format = DefaultFormat
+			expected, actual := implicit.Render(), explicit.Render()
+			if actual != expected {
+				t.Errorf("Expected DefaultFormat to render\n%q\ngot\n%q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatLineWidth(t *testing.T) {
+	bar := newLayoutBar(40)
+	bar.Format = "{message:20} {bar} {percent} {eta}"
+	bar.Width = 10
+	expected := "Downloading ubuntu-… [#####-----] 50%" // The ETA is dropped to fit
+	actual := visibleLine(bar.Render())
+	if actual != expected {
+		t.Errorf("Expected\n'%s'\ngot\n'%s'", expected, actual)
+	}
+}
+
+func TestParseFormatErrors(t *testing.T) {
+	tests := []struct {
+		format string
+		err    string
+	}{
+		{"{bar} {speed}", "unknown field 'speed'"},
+		{"{bar", "unclosed '{'"},
+		{"bar}", "unexpected '}'"},
+		{"{percent:x}", "invalid spec 'x' for field 'percent'"},
+		{"{message:.1f}", "invalid spec '.1f' for field 'message'"},
+		{"{bar|purple}", "unknown color 'purple'"},
+		{"{bar|reset}", "unknown color 'reset'"},
+	}
+	for _, tt := range tests {
+		_, err := ParseFormat(tt.format)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ParseFormat(%q): expected an error containing %q, got %v", tt.format, tt.err, err)
+		}
+	}
+}
+
+func TestManagerFormat(t *testing.T) {
+	m := NewManager()
+	if err := m.UpdateBar(Update{ID: "a", Format: "{bar"}); err == nil {
+		t.Errorf("Expected an error for an invalid format")
+	}
+	if _, exists := m.bars["a"]; exists {
+		t.Errorf("Expected an invalid format not to create the bar")
+	}
+	if err := m.UpdateBar(Update{ID: "a", Current: int64Ptr(1), Total: int64Ptr(4), Format: "{percent} {bar|red}"}); err != nil {
+		t.Fatalf("UpdateBar failed: %v", err)
+	}
+	m.UpdateBar(Update{ID: "a", Current: int64Ptr(2)})
+	if actual, expected := m.bars["a"].Render(), "50% \x1b[31m[#########################-------------------------]\x1b[0m"; actual != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, actual)
+	}
+}
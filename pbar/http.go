@@ -0,0 +1,88 @@
+package pbar
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// eventBuffer is the number of updates an /events client may fall behind
+// before its stream is ended.
+const eventBuffer = 256
+
+// NewHTTPHandler returns a handler reporting the bars of m to other
+// programs:
+//
+//	GET /bars    a JSON array with the Stats of every bar
+//	GET /events  a Server-Sent Events stream: an "update" event carrying the
+//	             Stats of each bar, then one per change, "remove" events
+//	             carrying {"id": ...} and "log" events carrying
+//	             {"message": ...}
+//	GET /metrics the bars in the Prometheus text format, see WriteMetrics
+func NewHTTPHandler(m *Manager) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bars", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m.Stats())
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		serveEvents(m, w, r)
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteMetrics(w)
+	})
+	return mux
+}
+
+func serveEvents(m *Manager, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// Subscribing first means no change can slip between the initial state
+	// and the stream; at worst a bar is reported twice
+	_, updates, cancel := m.Subscribe(eventBuffer)
+	defer cancel()
+	for _, s := range m.Stats() {
+		writeEvent(w, "update", s)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return // Fell behind
+			}
+			switch update.Op {
+			case OpRemove:
+				writeEvent(w, "remove", map[string]string{"id": update.ID})
+			case OpLog:
+				var message string
+				if update.Message != nil {
+					message = *update.Message
+				}
+				writeEvent(w, "log", map[string]string{"message": message})
+			default:
+				s, ok := m.BarStats(update.ID)
+				if !ok {
+					continue
+				}
+				writeEvent(w, "update", s)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, data any) {
+	encoded, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+}
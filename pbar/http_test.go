@@ -0,0 +1,124 @@
+package pbar
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPBars(t *testing.T) {
+	m := NewManager()
+	m.UpdateBar(Update{ID: "a", Current: int64Ptr(25), Total: int64Ptr(100), Message: stringPtr("copying")})
+	m.bars["a"].ThroughputHistory = []float64{5, 15}
+	m.UpdateBar(Update{ID: "b", Current: int64Ptr(3), Total: int64Ptr(4), Op: OpFail, Message: stringPtr("disk full")})
+	m.UpdateBar(Update{ID: "c", Current: int64Ptr(4), Total: int64Ptr(4), Finished: boolPtr(true)})
+
+	server := httptest.NewServer(NewHTTPHandler(m))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/bars")
+	if err != nil {
+		t.Fatalf("GET /bars failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON response, got '%s'", ct)
+	}
+
+	var stats []Stats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode /bars: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 bars, got %d", len(stats))
+	}
+	a, b, c := stats[0], stats[1], stats[2]
+	if a.ID != "a" || a.Current != 25 || a.Total != 100 || a.Percent != 25 || a.Rate != 10 || a.State != StateRunning || a.Message != "copying" {
+		t.Errorf("Unexpected stats for a running bar: %+v", a)
+	}
+	if a.ETA == nil || *a.ETA != 7.5 {
+		t.Errorf("Expected an ETA of 7.5s for bar a, got %v", a.ETA)
+	}
+	if b.State != StateFailed || b.Error != "disk full" || b.ETA != nil {
+		t.Errorf("Unexpected stats for a failed bar: %+v", b)
+	}
+	if c.State != StateFinished || c.Percent != 100 || c.ETA == nil || *c.ETA != 0 {
+		t.Errorf("Unexpected stats for a finished bar: %+v", c)
+	}
+}
+
+func TestHTTPEvents(t *testing.T) {
+	m := NewManager()
+	m.UpdateBar(Update{ID: "a", Current: int64Ptr(1), Total: int64Ptr(10)})
+
+	server := httptest.NewServer(NewHTTPHandler(m))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected an event stream, got '%s'", ct)
+	}
+
+	events := bufio.NewScanner(resp.Body)
+	next := func() (string, string) {
+		t.Helper()
+		var event, data string
+		for events.Scan() {
+			line := events.Text()
+			if line == "" {
+				return event, data
+			}
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				event = v
+			} else if v, ok := strings.CutPrefix(line, "data: "); ok {
+				data = v
+			}
+		}
+		t.Fatalf("Event stream ended: %v", events.Err())
+		return "", ""
+	}
+
+	// The initial state is flushed once the subscription is in place
+	if event, data := next(); event != "update" || !strings.Contains(data, `"id":"a","current":1,`) {
+		t.Errorf("Expected the initial state of bar a, got %s %s", event, data)
+	}
+
+	m.UpdateBar(Update{ID: "a", Delta: 4})
+	if event, data := next(); event != "update" || !strings.Contains(data, `"current":5,`) || !strings.Contains(data, `"percent":50,`) {
+		t.Errorf("Expected an update to 50%%, got %s %s", event, data)
+	}
+	m.UpdateBar(Update{Op: OpLog, Message: stringPtr("hello")})
+	if event, data := next(); event != "log" || data != `{"message":"hello"}` {
+		t.Errorf("Expected a log event, got %s %s", event, data)
+	}
+	m.UpdateBar(Update{ID: "a", Op: OpRemove})
+	if event, data := next(); event != "remove" || data != `{"id":"a"}` {
+		t.Errorf("Expected a remove event, got %s %s", event, data)
+	}
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	m := NewManager()
+	m.UpdateBar(Update{ID: "a", Current: int64Ptr(3), Total: int64Ptr(10)})
+	server := httptest.NewServer(NewHTTPHandler(m))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus text format, got '%s'", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `pbar_current{id="a"} 3`) {
+		t.Errorf("Expected the bar's metrics, got\n%s", body)
+	}
+}
@@ -0,0 +1,103 @@
+package pbar
+
+import "strings"
+
+// Drop ranks of the metadata fields. When a line is wider than
+// Bar.LineWidth, fields are dropped from the lowest rank up; fields with
+// rank 0 are always kept.
+const (
+	dropElapsed = iota + 1
+	dropRate
+	dropETA
+	dropAmount
+)
+
+const (
+	minAutoWidth    = 10 // Narrowest bar drawn by an AutoWidth bar before dropping metadata
+	minMessageWidth = 12 // Narrowest a message is shortened to before dropping the ETA
+)
+
+// fitLine renders columns from s so that the line fits within
+// b.LineWidth. In order, it drops the elapsed time and throughput, shortens
+// the message, drops the ETA and amount, drops the message and finally
+// narrows the bar. An AutoWidth bar then grows to fill the space left.
+func (b *Bar) fitLine(columns []Column, s Snapshot) string {
+	dropped := 0
+	render := func() string { return renderColumns(columns, s, dropped) }
+	if b.LineWidth <= 0 {
+		return render()
+	}
+	auto := b.AutoWidth && s.Width > 0
+	if auto {
+		s.Width = minAutoWidth
+	}
+	excess := func() int {
+		return displayWidth(render()) - b.LineWidth
+	}
+	steps := []func(){
+		func() { dropped = dropElapsed },
+		func() { dropped = dropRate },
+		func() { s.Message = ellipsize(s.Message, max(displayWidth(s.Message)-excess(), minMessageWidth)) },
+		func() { dropped = dropETA },
+		func() { dropped = dropAmount },
+		func() { s.Message = "" },
+		func() { s.Width = max(s.Width-excess(), 0) },
+	}
+	fullMessage := s.Message
+	for _, step := range steps {
+		if excess() <= 0 {
+			break
+		}
+		step()
+	}
+	if s.Message != "" && s.Message != fullMessage {
+		// Later steps may have freed room for more of the message
+		s.Message = ellipsize(fullMessage, displayWidth(s.Message)-excess())
+	}
+	if auto && excess() < 0 {
+		s.Width -= excess()
+	}
+	return render()
+}
+
+// clipLine shortens a rendered line that is still wider than b.LineWidth.
+func (b *Bar) clipLine(line string) string {
+	if b.LineWidth <= 0 {
+		return line
+	}
+	return ellipsize(line, b.LineWidth)
+}
+
+// ellipsize shortens s to at most width columns, marking the cut with "…".
+// Escape sequences are kept, and a reset is added if s had any.
+func ellipsize(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	var sb strings.Builder
+	used := 0
+	hasEscapes := false
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			sb.WriteString(s[i : i+n])
+			hasEscapes = true
+			i += n
+			continue
+		}
+		size, w := nextGrapheme(s[i:])
+		if used+w > width-1 {
+			break
+		}
+		sb.WriteString(s[i : i+size])
+		used += w
+		i += size
+	}
+	sb.WriteString("…")
+	if hasEscapes {
+		sb.WriteString(AnsiColors["reset"])
+	}
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package pbar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// visibleLine strips the redraw codes around a rendered line.
+func visibleLine(rendered string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(rendered, "\r"), "\x1b[K")
+}
+
+func newLayoutBar(lineWidth int) *Bar {
+	return &Bar{
+		Total:             100,
+		Current:           50,
+		Width:             20,
+		Style:             "classic",
+		LineWidth:         lineWidth,
+		StartTime:         time.Now().Add(-90 * time.Second),
+		ThroughputHistory: []float64{3, 3}, // Averages 2 it/s with the sample taken by Render
+		Message:           "Downloading ubuntu-24.04-desktop-amd64.iso",
+		ShowElapsed:       true,
+		ShowThroughput:    true,
+		ShowETA:           true,
+		TestMode:          true,
+	}
+}
+
+func TestLineWidth(t *testing.T) {
+	tests := []struct {
+		lineWidth int
+		expected  string
+	}{
+		{0, "[##########----------] 50% Elapsed 1m30s 2.00 it/s ETA 25s Downloading ubuntu-24.04-desktop-amd64.iso"},
+		{100, "[##########----------] 50% 2.00 it/s ETA 25s Downloading ubuntu-24.04-desktop-amd64.iso"},
+		{80, "[##########----------] 50% ETA 25s Downloading ubuntu-24.04-desktop-amd64.iso"},
+		{60, "[##########----------] 50% ETA 25s Downloading ubuntu-24.04…"},
+		{40, "[##########----------] 50% Downloading …"},
+		{30, "[##########----------] 50%"},
+		{20, "[#######-------] 50%"},
+		{3, "[]…"},
+	}
+	for _, tt := range tests {
+		bar := newLayoutBar(tt.lineWidth)
+		actual := visibleLine(bar.Render())
+		if actual != tt.expected {
+			t.Errorf("LineWidth %d: expected\n'%s'\ngot\n'%s'", tt.lineWidth, tt.expected, actual)
+		}
+		if tt.lineWidth > 0 && displayWidth(actual) > tt.lineWidth {
+			t.Errorf("LineWidth %d: line is %d columns wide", tt.lineWidth, displayWidth(actual))
+		}
+	}
+}
+
+func TestAutoWidth(t *testing.T) {
+	for _, lineWidth := range []int{120, 80, 50} {
+		bar := newLayoutBar(lineWidth)
+		bar.AutoWidth = true
+		bar.ColorBar = AnsiColors["green"]
+		actual := visibleLine(bar.Render())
+		if displayWidth(actual) != lineWidth {
+			t.Errorf("LineWidth %d: expected the bar to fill the line, got %d columns: '%s'", lineWidth, displayWidth(actual), actual)
+		}
+	}
+
+	bar := newLayoutBar(0)
+	bar.AutoWidth = true
+	if actual := visibleLine(bar.Render()); !strings.HasPrefix(actual, "[##########----------] 50%") {
+		t.Errorf("Expected the fixed width without a line width, got '%s'", actual)
+	}
+}
+
+func TestLineWidthWideMessage(t *testing.T) {
+	bar := newLayoutBar(50)
+	bar.Message = "正在下载 ubuntu-24.04 桌面版镜像文件"
+	expected := "[##########----------] 50% ETA 25s 正在下载 ubunt…" // 50 columns
+	if actual := visibleLine(bar.Render()); actual != expected {
+		t.Errorf("Expected\n'%s'\ngot\n'%s'", expected, actual)
+	}
+}
+
+func TestLineWidthStates(t *testing.T) {
+	bar := newLayoutBar(30)
+	bar.Fail("connection reset by peer while downloading")
+	if actual := visibleLine(bar.Render()); displayWidth(actual) > 30 || !strings.HasSuffix(actual, "…"+AnsiColors["reset"]) {
+		t.Errorf("Expected the failure line to be clipped, got '%s'", actual)
+	}
+
+	bar = newLayoutBar(40)
+	bar.Style = "spinner"
+	if actual := visibleLine(bar.Render()); displayWidth(actual) > 40 {
+		t.Errorf("Expected the spinner line to fit, got '%s'", actual)
+	}
+}
+
+func TestEllipsize(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 6, "hello…"},
+		{"hello", 1, "…"},
+		{"hello", 0, ""},
+		{"下载完成了", 6, "下载…"},                      // Wide characters are never cut in half
+		{"cafe\u0301 au lait", 5, "cafe\u0301…"}, // Accents stay with their letter
+		{"👍🏽👍🏽👍🏽", 5, "👍🏽👍🏽…"},
+	}
+	for _, tt := range tests {
+		if actual := ellipsize(tt.s, tt.width); actual != tt.expected {
+			t.Errorf("ellipsize(%q, %d): expected '%s', got '%s'", tt.s, tt.width, tt.expected, actual)
+		}
+	}
+}
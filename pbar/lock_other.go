@@ -0,0 +1,16 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package pbar
+
+import "os"
+
+// flock is not available on this platform. State writes are still atomic,
+// so readers never see torn files, but concurrent updates may be lost.
+
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package pbar
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -8,21 +9,39 @@ import (
 	"time"
 )
 
+// Operations carried by the Op field of an Update.
+const (
+	OpUpdate = "update" // Create or patch a bar (the default)
+	OpRemove = "remove" // Drop a bar from the display
+	OpFail   = "fail"   // Mark a bar as failed; Message is the failure reason
+	OpPause  = "pause"  // Freeze the elapsed time, throughput and ETA of a bar
+	OpResume = "resume" // Restart the clocks of a paused bar
+	OpLog    = "log"    // Print Message permanently above the bars
+)
+
 // Update represents an update for a single progress bar.
+// Updates are patches: nil pointer fields and empty strings were omitted by
+// the producer and leave the bar's existing value untouched.
 type Update struct {
-	ID             string `json:"id"`
-	Current        int    `json:"current"`
-	Total          int    `json:"total"`
-	Width          int    `json:"width"`
-	Style          string `json:"style"`
-	ColorBar       string `json:"colorbar"`
-	ColorText      string `json:"colortext"`
-	Finished       bool   `json:"finished"`
-	CustomChars    string `json:"chars"`
-	Message        string `json:"message"`
-	ShowElapsed    *bool  `json:"showelapsed,omitempty"`
-	ShowThroughput *bool  `json:"showthroughput,omitempty"`
-	ShowETA        *bool  `json:"showeta,omitempty"`
+	ID             string  `json:"id"`
+	Op             string  `json:"op,omitempty"`
+	Current        *int64  `json:"current,omitempty"`
+	Delta          int64   `json:"delta,omitempty"` // Added to the bar's current value after Current is applied
+	Total          *int64  `json:"total,omitempty"`
+	Width          int     `json:"width,omitempty"`
+	Style          string  `json:"style,omitempty"`
+	Unit           string  `json:"unit,omitempty"`
+	ColorBar       string  `json:"colorbar,omitempty"`
+	ColorText      string  `json:"colortext,omitempty"`
+	Finished       *bool   `json:"finished,omitempty"`
+	CustomChars    string  `json:"chars,omitempty"`
+	Format         string  `json:"format,omitempty"` // Layout of the line, see ParseFormat
+	Message        *string `json:"message,omitempty"`
+	ShowElapsed    *bool   `json:"showelapsed,omitempty"`
+	ShowThroughput *bool   `json:"showthroughput,omitempty"`
+	ShowETA        *bool   `json:"showeta,omitempty"`
+
+	StartTime *time.Time `json:"starttime,omitempty"` // Sent to viewers so that their copy keeps the bar's clocks; only a Mirror applies it
 }
 
 // Manager manages multiple progress bars.
@@ -30,7 +49,29 @@ type Manager struct {
 	bars      map[string]*Bar
 	order     []string // To maintain the order of bars
 	mu        sync.Mutex
-	lastLines int // Number of lines printed in the last render cycle
+	lastLines int      // Number of lines printed in the last render cycle
+	logs      []string // Lines waiting to be printed above the bars
+	subs      map[chan Update]*subscription
+
+	parseErrors int64 // Updates that could not be parsed, see RecordParseError
+
+	// LineWidth, if positive, is the number of columns each bar's line must
+	// fit in (see Bar.LineWidth).
+	LineWidth int
+
+	// AutoFinish marks a bar as finished once its current value reaches a
+	// positive total, unless the update sets Finished itself.
+	AutoFinish bool
+
+	// Mirror makes the manager a copy of another one, fed by the updates of
+	// Subscribe. It takes the start time of each bar from the updates
+	// instead of starting the clock when the bar is created.
+	Mirror bool
+}
+
+// subscription is the state of a channel handed out by Subscribe.
+type subscription struct {
+	dropped bool // Ended by publish because the subscriber fell behind
 }
 
 // NewManager creates a new Manager instance.
@@ -40,12 +81,40 @@ func NewManager() *Manager {
 	}
 }
 
-// UpdateBar creates or updates a progress bar.
-func (m *Manager) UpdateBar(update Update) {
+// UpdateBar creates or updates a progress bar, or performs the operation
+// named by update.Op.
+func (m *Manager) UpdateBar(update Update) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	switch update.Op {
+	case "", OpUpdate, OpFail, OpPause, OpResume:
+	case OpRemove:
+		m.removeBar(update.ID)
+		m.publish(update)
+		return nil
+	case OpLog:
+		if update.Message != nil {
+			m.logs = append(m.logs, *update.Message)
+		}
+		m.publish(update)
+		return nil
+	default:
+		return fmt.Errorf("unknown op '%s'", update.Op)
+	}
+
+	if update.Width < 0 {
+		return errors.New("width must be positive") // Same as Bar.Validate; 0 leaves the width unchanged
+	}
+
 	bar, exists := m.bars[update.ID]
+	var format *Format
+	if update.Format != "" && (!exists || update.Format != bar.Format) {
+		var err error
+		if format, err = ParseFormat(update.Format); err != nil {
+			return err
+		}
+	}
 	if !exists {
 		bar = &Bar{
 			StartTime:      time.Now(),
@@ -59,9 +128,17 @@ func (m *Manager) UpdateBar(update Update) {
 		sort.Strings(m.order) // Keep bars sorted by ID for consistent display
 	}
 
-	// Apply updates
-	bar.Current = update.Current
-	bar.Total = update.Total
+	// Apply updates, leaving omitted fields as they are
+	if update.StartTime != nil && m.Mirror {
+		bar.StartTime = *update.StartTime
+	}
+	if update.Current != nil {
+		bar.Current = *update.Current
+	}
+	bar.Current += update.Delta
+	if update.Total != nil {
+		bar.Total = *update.Total
+	}
 	if update.Width > 0 {
 		bar.Width = update.Width
 	} else if bar.Width == 0 { // Set default width if not provided and not already set
@@ -72,17 +149,29 @@ func (m *Manager) UpdateBar(update Update) {
 	} else if bar.Style == "" { // Set default style if not provided and not already set
 		bar.Style = defaultStyle
 	}
+	if update.Unit != "" {
+		bar.Unit = update.Unit
+	}
 	if update.ColorBar != "" {
 		bar.ColorBar = GetColorCode(update.ColorBar)
 	}
 	if update.ColorText != "" {
 		bar.ColorText = GetColorCode(update.ColorText)
 	}
-	bar.Finished = update.Finished
+	if update.Finished != nil {
+		bar.Finished = *update.Finished
+	} else if m.AutoFinish && bar.Total > 0 && bar.Current >= bar.Total {
+		bar.Finished = true
+	}
 	if update.CustomChars != "" {
 		bar.CustomChars = update.CustomChars
 	}
-	bar.Message = update.Message
+	if format != nil {
+		bar.Format, bar.format = update.Format, format
+	}
+	if update.Message != nil && update.Op != OpFail {
+		bar.Message = *update.Message
+	}
 	if update.ShowElapsed != nil {
 		bar.ShowElapsed = *update.ShowElapsed
 	}
@@ -92,6 +181,146 @@ func (m *Manager) UpdateBar(update Update) {
 	if update.ShowETA != nil {
 		bar.ShowETA = *update.ShowETA
 	}
+
+	switch update.Op {
+	case OpFail:
+		var reason string
+		if update.Message != nil {
+			reason = *update.Message
+		}
+		bar.Fail(reason)
+	case OpPause:
+		bar.Pause()
+	case OpResume:
+		bar.Resume()
+	}
+
+	// Subscribers get the resulting values, so they need not know about
+	// deltas or AutoFinish to stay in sync
+	current, finished, startTime := bar.Current, bar.Finished, bar.StartTime
+	update.Current, update.Delta = &current, 0
+	update.Finished = &finished
+	update.StartTime = &startTime
+	m.publish(update)
+	return nil
+}
+
+// Stats returns a summary of every bar, in display order.
+func (m *Manager) Stats() []Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]Stats, 0, len(m.order))
+	for _, id := range m.order {
+		s := m.bars[id].Stats()
+		s.ID = id
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// BarStats returns a summary of the bar with the given ID, if it exists.
+func (m *Manager) BarStats(id string) (Stats, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bar, ok := m.bars[id]
+	if !ok {
+		return Stats{}, false
+	}
+	s := bar.Stats()
+	s.ID = id
+	return s, true
+}
+
+// Subscribe returns updates that recreate every bar in its current state,
+// and a channel that receives every update applied after that, in order.
+// The subscription ends, closing the channel, when cancel is called or when
+// the subscriber falls more than buffer updates behind, so a slow
+// subscriber never holds up the producers. cancel reports whether the
+// subscription had ended for the latter reason.
+func (m *Manager) Subscribe(buffer int) (snapshot []Update, updates <-chan Update, cancel func() (dropped bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range m.order {
+		snapshot = append(snapshot, snapshotUpdates(id, m.bars[id])...)
+	}
+	ch := make(chan Update, buffer)
+	if m.subs == nil {
+		m.subs = make(map[chan Update]*subscription)
+	}
+	sub := &subscription{}
+	m.subs[ch] = sub
+
+	cancel = func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.subs[ch]; ok {
+			delete(m.subs, ch)
+			close(ch)
+		}
+		return sub.dropped
+	}
+	return snapshot, ch, cancel
+}
+
+// publish sends update to the subscribers, dropping those that are full.
+// It must be called with m.mu held.
+func (m *Manager) publish(update Update) {
+	for ch, sub := range m.subs {
+		select {
+		case ch <- update:
+		default:
+			sub.dropped = true
+			delete(m.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// snapshotUpdates returns the updates that recreate bar under id.
+func snapshotUpdates(id string, bar *Bar) []Update {
+	current, total, finished, startTime := bar.Current, bar.Total, bar.Finished, bar.StartTime
+	message := bar.Message
+	showElapsed, showThroughput, showETA := bar.ShowElapsed, bar.ShowThroughput, bar.ShowETA
+	updates := []Update{{
+		ID:             id,
+		Current:        &current,
+		Total:          &total,
+		Width:          bar.Width,
+		Style:          bar.Style,
+		Unit:           bar.Unit,
+		ColorBar:       colorName(bar.ColorBar),
+		ColorText:      colorName(bar.ColorText),
+		Finished:       &finished,
+		CustomChars:    bar.CustomChars,
+		Format:         bar.Format,
+		Message:        &message,
+		ShowElapsed:    &showElapsed,
+		ShowThroughput: &showThroughput,
+		ShowETA:        &showETA,
+		StartTime:      &startTime,
+	}}
+	if bar.Failed {
+		reason := bar.FailureMessage
+		updates = append(updates, Update{ID: id, Op: OpFail, Message: &reason})
+	}
+	if bar.Paused {
+		updates = append(updates, Update{ID: id, Op: OpPause})
+	}
+	return updates
+}
+
+func (m *Manager) removeBar(id string) {
+	if _, exists := m.bars[id]; !exists {
+		return
+	}
+	delete(m.bars, id)
+	for i, existing := range m.order {
+		if existing == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
 }
 
 // RenderAll renders all managed progress bars to the terminal.
@@ -111,9 +340,19 @@ func (m *Manager) RenderAll() {
 		}
 	}
 
+	// Log lines are printed where the bars were and scroll up with the output
+	for _, line := range m.logs {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	m.logs = nil
+
 	var outputLines []string
 	for _, id := range m.order {
 		bar := m.bars[id]
+		if m.LineWidth > 0 {
+			bar.LineWidth = m.LineWidth
+		}
 		outputLines = append(outputLines, bar.Render())
 	}
 
@@ -138,4 +377,10 @@ func (m *Manager) Clear() {
 	}
 	// Reset lastLines after clearing
 	m.lastLines = 0
+
+	// Flush any log lines that never got a render cycle
+	for _, line := range m.logs {
+		fmt.Println(line)
+	}
+	m.logs = nil
 }
@@ -0,0 +1,306 @@
+package pbar
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+func TestManagerDelta(t *testing.T) {
+	t.Run("delta updates add to the current value", func(t *testing.T) {
+		m := NewManager()
+		m.UpdateBar(Update{ID: "a", Current: int64Ptr(10), Total: int64Ptr(100), Message: stringPtr("working")})
+		m.UpdateBar(Update{ID: "a", Delta: 1})
+		m.UpdateBar(Update{ID: "a", Delta: 5})
+
+		bar := m.bars["a"]
+		if bar.Current != 16 {
+			t.Errorf("Expected current 16, got %d", bar.Current)
+		}
+		if bar.Total != 100 {
+			t.Errorf("Expected total to stay at 100, got %d", bar.Total)
+		}
+		if bar.Message != "working" {
+			t.Errorf("Expected message to be kept, got '%s'", bar.Message)
+		}
+	})
+
+	t.Run("absolute updates still replace the current value", func(t *testing.T) {
+		m := NewManager()
+		m.UpdateBar(Update{ID: "a", Delta: 3, Total: int64Ptr(10)})
+		m.UpdateBar(Update{ID: "a", Current: int64Ptr(7), Total: int64Ptr(10)})
+
+		if got := m.bars["a"].Current; got != 7 {
+			t.Errorf("Expected current 7, got %d", got)
+		}
+	})
+}
+
+func TestManagerPartialUpdates(t *testing.T) {
+	apply := func(m *Manager, line string) {
+		t.Helper()
+		var update Update
+		if err := json.Unmarshal([]byte(line), &update); err != nil {
+			t.Fatalf("Failed to parse '%s': %v", line, err)
+		}
+		m.UpdateBar(update)
+	}
+
+	t.Run("message-only update keeps progress", func(t *testing.T) {
+		m := NewManager()
+		apply(m, `{"id":"a","current":40,"total":80,"message":"downloading"}`)
+		apply(m, `{"id":"a","message":"extracting"}`)
+
+		bar := m.bars["a"]
+		if bar.Current != 40 || bar.Total != 80 {
+			t.Errorf("Expected 40/80, got %d/%d", bar.Current, bar.Total)
+		}
+		if bar.Message != "extracting" {
+			t.Errorf("Expected message 'extracting', got '%s'", bar.Message)
+		}
+	})
+
+	t.Run("explicit zero and empty values are applied", func(t *testing.T) {
+		m := NewManager()
+		apply(m, `{"id":"a","current":40,"total":80,"message":"downloading","finished":true}`)
+		apply(m, `{"id":"a","current":0,"message":"","finished":false}`)
+
+		bar := m.bars["a"]
+		if bar.Current != 0 {
+			t.Errorf("Expected current 0, got %d", bar.Current)
+		}
+		if bar.Total != 80 {
+			t.Errorf("Expected total 80, got %d", bar.Total)
+		}
+		if bar.Message != "" {
+			t.Errorf("Expected empty message, got '%s'", bar.Message)
+		}
+		if bar.Finished {
+			t.Errorf("Expected bar to be unfinished")
+		}
+	})
+
+	t.Run("full updates behave as before", func(t *testing.T) {
+		m := NewManager()
+		apply(m, `{"id":"a","current":10,"total":100,"message":"m1","style":"block","colorbar":"green"}`)
+		apply(m, `{"id":"a","current":20,"total":50,"message":"m2","finished":true}`)
+
+		bar := m.bars["a"]
+		if bar.Current != 20 || bar.Total != 50 || bar.Message != "m2" || !bar.Finished {
+			t.Errorf("Unexpected bar state: %d/%d '%s' finished=%v", bar.Current, bar.Total, bar.Message, bar.Finished)
+		}
+		if bar.Style != "block" {
+			t.Errorf("Expected style to be kept, got '%s'", bar.Style)
+		}
+	})
+}
+
+func TestManagerOps(t *testing.T) {
+	t.Run("remove drops the bar and its position", func(t *testing.T) {
+		m := NewManager()
+		m.UpdateBar(Update{ID: "a", Current: int64Ptr(1), Total: int64Ptr(10)})
+		m.UpdateBar(Update{ID: "b", Current: int64Ptr(2), Total: int64Ptr(10)})
+		if err := m.UpdateBar(Update{ID: "a", Op: OpRemove}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if _, exists := m.bars["a"]; exists {
+			t.Errorf("Expected bar 'a' to be removed")
+		}
+		if len(m.order) != 1 || m.order[0] != "b" {
+			t.Errorf("Expected order [b], got %v", m.order)
+		}
+	})
+
+	t.Run("fail marks the bar failed with the message as reason", func(t *testing.T) {
+		m := NewManager()
+		m.UpdateBar(Update{ID: "a", Current: int64Ptr(5), Total: int64Ptr(10), Message: stringPtr("deploying")})
+		m.UpdateBar(Update{ID: "a", Op: OpFail, Message: stringPtr("health check timed out")})
+
+		bar := m.bars["a"]
+		if !bar.Failed {
+			t.Fatalf("Expected bar to be failed")
+		}
+		if bar.FailureMessage != "health check timed out" {
+			t.Errorf("Unexpected failure message '%s'", bar.FailureMessage)
+		}
+		if bar.Message != "deploying" {
+			t.Errorf("Expected message to be kept, got '%s'", bar.Message)
+		}
+	})
+
+	t.Run("pause and resume toggle the paused state", func(t *testing.T) {
+		m := NewManager()
+		m.UpdateBar(Update{ID: "a", Current: int64Ptr(5), Total: int64Ptr(10)})
+		m.UpdateBar(Update{ID: "a", Op: OpPause})
+		if !m.bars["a"].Paused {
+			t.Fatalf("Expected bar to be paused")
+		}
+		m.UpdateBar(Update{ID: "a", Op: OpResume})
+		if m.bars["a"].Paused {
+			t.Errorf("Expected bar to be resumed")
+		}
+	})
+
+	t.Run("log queues a line without creating a bar", func(t *testing.T) {
+		m := NewManager()
+		m.UpdateBar(Update{Op: OpLog, Message: stringPtr("step 1 done")})
+
+		if len(m.bars) != 0 {
+			t.Errorf("Expected no bars, got %d", len(m.bars))
+		}
+		if len(m.logs) != 1 || m.logs[0] != "step 1 done" {
+			t.Errorf("Expected queued log line, got %v", m.logs)
+		}
+	})
+
+	t.Run("negative width is rejected", func(t *testing.T) {
+		m := NewManager()
+		if err := m.UpdateBar(Update{ID: "a", Width: -5}); err == nil {
+			t.Errorf("Expected an error for a negative width")
+		}
+		if len(m.bars) != 0 {
+			t.Errorf("Expected no bar to be created")
+		}
+	})
+
+	t.Run("unknown op is rejected", func(t *testing.T) {
+		m := NewManager()
+		if err := m.UpdateBar(Update{ID: "a", Op: "explode"}); err == nil {
+			t.Errorf("Expected an error for an unknown op")
+		}
+		if len(m.bars) != 0 {
+			t.Errorf("Expected no bar to be created")
+		}
+	})
+}
+
+func TestManagerAutoFinish(t *testing.T) {
+	m := NewManager()
+	m.AutoFinish = true
+	m.UpdateBar(Update{ID: "a", Current: int64Ptr(9), Total: int64Ptr(10)})
+	if m.bars["a"].Finished {
+		t.Errorf("Expected the bar to be running below its total")
+	}
+	m.UpdateBar(Update{ID: "a", Delta: 1})
+	if !m.bars["a"].Finished {
+		t.Errorf("Expected the bar to finish when it reaches its total")
+	}
+
+	m.UpdateBar(Update{ID: "b", Current: int64Ptr(5)})
+	if m.bars["b"].Finished {
+		t.Errorf("Expected a bar without a total to keep running")
+	}
+}
+
+func TestManagerSubscribe(t *testing.T) {
+	t.Run("snapshot and updates recreate the bars", func(t *testing.T) {
+		m := NewManager()
+		m.UpdateBar(Update{ID: "a", Current: int64Ptr(10), Total: int64Ptr(100), ColorBar: "green", Message: stringPtr("copying")})
+		m.UpdateBar(Update{ID: "b", Total: int64Ptr(5), Op: OpFail, Message: stringPtr("disk full")})
+		m.UpdateBar(Update{ID: "c", Op: OpPause})
+
+		snapshot, updates, cancel := m.Subscribe(10)
+		defer cancel()
+		m.UpdateBar(Update{ID: "a", Delta: 5})
+		m.UpdateBar(Update{ID: "c", Op: OpRemove})
+
+		viewer := NewManager()
+		viewer.Mirror = true
+		for _, update := range snapshot {
+			if err := viewer.UpdateBar(update); err != nil {
+				t.Fatalf("Failed to apply snapshot update %+v: %v", update, err)
+			}
+		}
+		for i := 0; i < 2; i++ {
+			viewer.UpdateBar(<-updates)
+		}
+
+		a := viewer.bars["a"]
+		if a.Current != 15 || a.Total != 100 || a.Message != "copying" || a.ColorBar != AnsiColors["green"] {
+			t.Errorf("Expected bar a to be recreated, got %+v", a)
+		}
+		if !a.StartTime.Equal(m.bars["a"].StartTime) {
+			t.Errorf("Expected bar a to keep its start time")
+		}
+		if b := viewer.bars["b"]; !b.Failed || b.FailureMessage != "disk full" {
+			t.Errorf("Expected bar b to be failed, got %+v", b)
+		}
+		if _, exists := viewer.bars["c"]; exists {
+			t.Errorf("Expected bar c to be removed")
+		}
+	})
+
+	t.Run("only a mirror takes the start time from updates", func(t *testing.T) {
+		started := time.Now().Add(-time.Hour)
+		m := NewManager()
+		m.UpdateBar(Update{ID: "a", StartTime: &started})
+		if m.bars["a"].StartTime.Equal(started) {
+			t.Errorf("Expected a producer's start time to be ignored")
+		}
+
+		snapshot, _, cancel := m.Subscribe(1)
+		cancel()
+		data, err := json.Marshal(snapshot[0])
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if !strings.Contains(string(data), `"starttime":`) {
+			t.Errorf("Expected the start time in the snapshot, got %s", data)
+		}
+
+		var update Update
+		if err := json.Unmarshal(data, &update); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		viewer := NewManager()
+		viewer.Mirror = true
+		viewer.UpdateBar(update)
+		if !viewer.bars["a"].StartTime.Equal(m.bars["a"].StartTime) {
+			t.Errorf("Expected the mirror to keep the start time, got %v", viewer.bars["a"].StartTime)
+		}
+	})
+
+	t.Run("slow subscribers are dropped", func(t *testing.T) {
+		m := NewManager()
+		_, updates, cancel := m.Subscribe(2)
+		defer cancel()
+		for i := 0; i < 5; i++ {
+			m.UpdateBar(Update{ID: "a", Delta: 1}) // Must not block
+		}
+
+		received := 0
+		for range updates {
+			received++
+		}
+		if received != 2 {
+			t.Errorf("Expected the 2 buffered updates before the channel closed, got %d", received)
+		}
+		if !cancel() {
+			t.Errorf("Expected cancel to report that the subscriber was dropped")
+		}
+	})
+
+	t.Run("cancelled subscribers are not reported as dropped", func(t *testing.T) {
+		m := NewManager()
+		_, updates, cancel := m.Subscribe(2)
+		m.UpdateBar(Update{ID: "a", Delta: 1})
+		if cancel() {
+			t.Errorf("Expected cancel to report a subscriber that kept up")
+		}
+		for range updates {
+		}
+		if cancel() {
+			t.Errorf("Expected a second cancel to report the same")
+		}
+	})
+}
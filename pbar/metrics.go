@@ -0,0 +1,86 @@
+package pbar
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// metricGauges describes the per-bar gauges written by WriteMetrics.
+var metricGauges = []struct {
+	name, help string
+	value      func(s Stats) (float64, bool)
+}{
+	{"pbar_current", "Current value of the bar.", func(s Stats) (float64, bool) { return float64(s.Current), true }},
+	{"pbar_total", "Total of the bar.", func(s Stats) (float64, bool) { return float64(s.Total), true }},
+	{"pbar_rate", "Average throughput of the bar, in units per second.", func(s Stats) (float64, bool) { return s.Rate, true }},
+	{"pbar_eta_seconds", "Estimated time left, in seconds. Missing while unknown.", func(s Stats) (float64, bool) {
+		if s.ETA == nil {
+			return 0, false
+		}
+		return *s.ETA, true
+	}},
+	{"pbar_finished", "Whether the bar has finished (1) or not (0).", func(s Stats) (float64, bool) { return boolMetric(s.State == StateFinished), true }},
+	{"pbar_failed", "Whether the bar has failed (1) or not (0).", func(s Stats) (float64, bool) { return boolMetric(s.State == StateFailed), true }},
+}
+
+// RecordParseError counts an update that could not be parsed. The count is
+// reported by WriteMetrics.
+func (m *Manager) RecordParseError() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parseErrors++
+}
+
+// WriteMetrics writes the state of every bar in the Prometheus text
+// exposition format, with the bar ID as the "id" label.
+func (m *Manager) WriteMetrics(w io.Writer) error {
+	stats := m.Stats()
+	m.mu.Lock()
+	parseErrors := m.parseErrors
+	m.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, gauge := range metricGauges {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s gauge\n", gauge.name, gauge.help, gauge.name)
+		for _, s := range stats {
+			if v, ok := gauge.value(s); ok {
+				fmt.Fprintf(&buf, "%s{id=\"%s\"} %s\n", gauge.name, escapeLabel(s.ID), formatMetric(v))
+			}
+		}
+	}
+	buf.WriteString("# HELP pbar_parse_errors_total Updates that could not be parsed.\n# TYPE pbar_parse_errors_total counter\n")
+	fmt.Fprintf(&buf, "pbar_parse_errors_total %d\n", parseErrors)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteMetricsFile atomically replaces the file at path with the output of
+// WriteMetrics, so collectors reading it never see a partial file.
+func (m *Manager) WriteMetricsFile(path string) error {
+	var buf bytes.Buffer
+	if err := m.WriteMetrics(&buf); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0644)
+}
+
+func boolMetric(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
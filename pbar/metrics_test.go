@@ -0,0 +1,61 @@
+package pbar
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	m := NewManager()
+	m.UpdateBar(Update{ID: "a", Current: int64Ptr(25), Total: int64Ptr(100)})
+	m.bars["a"].ThroughputHistory = []float64{10}
+	m.UpdateBar(Update{ID: `b "quoted"`, Current: int64Ptr(4), Total: int64Ptr(4), Finished: boolPtr(true)})
+	m.UpdateBar(Update{ID: "c", Current: int64Ptr(1), Total: int64Ptr(4)})
+	m.RecordParseError()
+	m.RecordParseError()
+
+	var buf bytes.Buffer
+	if err := m.WriteMetrics(&buf); err != nil {
+		t.Fatalf("WriteMetrics failed: %v", err)
+	}
+	actual := buf.String()
+	for _, expected := range []string{
+		"# TYPE pbar_current gauge\npbar_current{id=\"a\"} 25\npbar_current{id=\"b \\\"quoted\\\"\"} 4\npbar_current{id=\"c\"} 1\n",
+		"pbar_total{id=\"a\"} 100\n",
+		"pbar_rate{id=\"a\"} 10\n",
+		"pbar_eta_seconds{id=\"a\"} 7.5\n",
+		"pbar_eta_seconds{id=\"b \\\"quoted\\\"\"} 0\n",
+		"pbar_finished{id=\"a\"} 0\npbar_finished{id=\"b \\\"quoted\\\"\"} 1\n",
+		"# TYPE pbar_parse_errors_total counter\npbar_parse_errors_total 2\n",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("Expected metrics to contain\n%s\ngot\n%s", expected, actual)
+		}
+	}
+	if strings.Contains(actual, `pbar_eta_seconds{id="c"}`) {
+		t.Errorf("Expected no ETA for a bar without throughput, got\n%s", actual)
+	}
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	m := NewManager()
+	m.UpdateBar(Update{ID: "a", Current: int64Ptr(1), Total: int64Ptr(2)})
+	path := filepath.Join(t.TempDir(), "pbar.prom")
+	if err := m.WriteMetricsFile(path); err != nil {
+		t.Fatalf("WriteMetricsFile failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read metrics file: %v", err)
+	}
+	if !strings.Contains(string(data), `pbar_current{id="a"} 1`) {
+		t.Errorf("Expected the metrics in the file, got\n%s", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,144 @@
+package pbar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Progress is a progress reading extracted from a line of tool output.
+type Progress struct {
+	Current   int64
+	Total     int64   // Zero when the line does not carry a total
+	Percent   float64 // Completion in the range 0-100, set when IsPercent is true
+	IsPercent bool    // The reading is a percentage rather than a count
+}
+
+// Apply moves the bar to the reading. Percentages are scaled to the bar's
+// total, which defaults to 100 when it is not known yet.
+func (p Progress) Apply(b *Bar) {
+	if p.IsPercent {
+		if b.Total <= 0 {
+			b.Total = 100
+		}
+		b.Current = int64(p.Percent / 100 * float64(b.Total))
+		return
+	}
+	if p.Total > 0 {
+		b.Total = p.Total
+	}
+	b.Current = p.Current
+}
+
+// LineParser extracts progress readings from lines of command output.
+type LineParser interface {
+	// ParseLine returns the reading found in line, if any.
+	ParseLine(line string) (Progress, bool)
+}
+
+// RegexParser reads progress from lines matching a regular expression.
+// Capture groups named current, total and percent are used when present.
+// Otherwise two unnamed groups are read as current and total, and a single
+// group as a percentage.
+type RegexParser struct {
+	re                           *regexp.Regexp
+	currentIdx, totalIdx, pctIdx int
+}
+
+// NewRegexParser compiles expr into a RegexParser.
+func NewRegexParser(expr string) (*RegexParser, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &RegexParser{
+		re:         re,
+		currentIdx: re.SubexpIndex("current"),
+		totalIdx:   re.SubexpIndex("total"),
+		pctIdx:     re.SubexpIndex("percent"),
+	}
+	if p.currentIdx < 0 && p.pctIdx < 0 {
+		switch re.NumSubexp() {
+		case 1:
+			p.pctIdx = 1
+		case 2:
+			p.currentIdx, p.totalIdx = 1, 2
+		default:
+			return nil, errors.New("expression must capture either current and total, or a percentage")
+		}
+	}
+	return p, nil
+}
+
+// ParseLine implements LineParser.
+func (p *RegexParser) ParseLine(line string) (Progress, bool) {
+	m := p.re.FindStringSubmatch(line)
+	if m == nil {
+		return Progress{}, false
+	}
+	if p.pctIdx >= 0 && m[p.pctIdx] != "" {
+		pct, err := parseNumber(m[p.pctIdx])
+		if err != nil {
+			return Progress{}, false
+		}
+		return Progress{Percent: pct, IsPercent: true}, true
+	}
+	if p.currentIdx < 0 {
+		return Progress{}, false
+	}
+	current, err := parseNumber(m[p.currentIdx])
+	if err != nil {
+		return Progress{}, false
+	}
+	progress := Progress{Current: int64(current)}
+	if p.totalIdx >= 0 && m[p.totalIdx] != "" {
+		total, err := parseNumber(m[p.totalIdx])
+		if err != nil {
+			return Progress{}, false
+		}
+		progress.Total = int64(total)
+	}
+	return progress, true
+}
+
+// parseNumber parses a decimal number, ignoring thousands separators.
+func parseNumber(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s'", s)
+	}
+	return v, nil
+}
+
+// ScanProgressLines is a bufio.SplitFunc that splits on "\n", "\r\n" and a
+// lone "\r", the latter being how most tools redraw their progress line.
+// Empty redraw segments are skipped.
+func ScanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// A trailing '\r' may be the first half of "\r\n"
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		if i == 0 {
+			return 1, nil, nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,94 @@
+package pbar
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegexParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		line     string
+		expected Progress
+		ok       bool
+	}{
+		{"current and total", `(\d+)/(\d+)`, "building 12/340 targets", Progress{Current: 12, Total: 340}, true},
+		{"thousands separators", `([\d,]+) of ([\d,]+)`, "1,024 of 4,096 bytes", Progress{Current: 1024, Total: 4096}, true},
+		{"percentage", `(\d+(?:\.\d+)?)%`, "progress: 42.5%", Progress{Percent: 42.5, IsPercent: true}, true},
+		{"named groups", `done=(?P<current>\d+) (?:\w+)=(\d+) of=(?P<total>\d+)`, "done=3 skip=1 of=9", Progress{Current: 3, Total: 9}, true},
+		{"named current only", `item (?P<current>\d+)`, "item 7", Progress{Current: 7}, true},
+		{"no match", `(\d+)/(\d+)`, "warning: something happened", Progress{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewRegexParser(tt.expr)
+			if err != nil {
+				t.Fatalf("Failed to compile '%s': %v", tt.expr, err)
+			}
+			actual, ok := parser.ParseLine(tt.line)
+			if ok != tt.ok || actual != tt.expected {
+				t.Errorf("Expected %+v (%v), got %+v (%v)", tt.expected, tt.ok, actual, ok)
+			}
+		})
+	}
+
+	t.Run("rejects expressions without usable groups", func(t *testing.T) {
+		if _, err := NewRegexParser(`\d+`); err == nil {
+			t.Errorf("Expected an error for an expression without groups")
+		}
+		if _, err := NewRegexParser(`(\d+)`); err != nil {
+			t.Errorf("Expected a single group to be accepted, got %v", err)
+		}
+	})
+}
+
+func TestProgressApply(t *testing.T) {
+	t.Run("counts replace current and total", func(t *testing.T) {
+		bar := &Bar{Total: 10}
+		Progress{Current: 5, Total: 20}.Apply(bar)
+		if bar.Current != 5 || bar.Total != 20 {
+			t.Errorf("Expected 5/20, got %d/%d", bar.Current, bar.Total)
+		}
+	})
+
+	t.Run("counts without total keep the bar total", func(t *testing.T) {
+		bar := &Bar{Total: 10}
+		Progress{Current: 5}.Apply(bar)
+		if bar.Current != 5 || bar.Total != 10 {
+			t.Errorf("Expected 5/10, got %d/%d", bar.Current, bar.Total)
+		}
+	})
+
+	t.Run("percentages are scaled to the total", func(t *testing.T) {
+		bar := &Bar{Total: 200}
+		Progress{Percent: 25, IsPercent: true}.Apply(bar)
+		if bar.Current != 50 {
+			t.Errorf("Expected 50, got %d", bar.Current)
+		}
+
+		bar = &Bar{}
+		Progress{Percent: 25, IsPercent: true}.Apply(bar)
+		if bar.Current != 25 || bar.Total != 100 {
+			t.Errorf("Expected 25/100, got %d/%d", bar.Current, bar.Total)
+		}
+	})
+}
+
+func TestScanProgressLines(t *testing.T) {
+	input := "first\nsecond\r\n\r  1%\r 50%\r100%\nlast"
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	scanner.Split(ScanProgressLines)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	expected := []string{"first", "second", "  1%", " 50%", "100%", "last"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+}
@@ -0,0 +1,245 @@
+package pbar
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// presetParsers maps the preset names accepted by NewPresetParser to their
+// constructors. Presets may keep state between lines (for example a total
+// announced in a header), so each command needs its own instance.
+var presetParsers = map[string]func() LineParser{
+	"rsync":  func() LineParser { return &rsyncParser{} },
+	"curl":   func() LineParser { return &curlParser{} },
+	"dd":     func() LineParser { return &ddParser{} },
+	"ffmpeg": func() LineParser { return &ffmpegParser{} },
+	"wget":   func() LineParser { return &wgetParser{} },
+	"pip":    func() LineParser { return &pipParser{} },
+}
+
+// NewPresetParser returns a parser for the native progress output of a
+// well-known tool. The returned parser is not safe for concurrent use.
+func NewPresetParser(name string) (LineParser, error) {
+	newParser, ok := presetParsers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format '%s'. Available: %s", name, strings.Join(PresetNames(), ", "))
+	}
+	return newParser(), nil
+}
+
+// PresetNames returns the sorted names of the available preset parsers.
+func PresetNames() []string {
+	names := make([]string, 0, len(presetParsers))
+	for name := range presetParsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rsyncParser reads "rsync --info=progress2" (or --progress) lines such as
+// "  32,768,000  31%   31.25MB/s    0:00:02". rsync does not print the total,
+// so it is derived from the transferred bytes and the percentage.
+type rsyncParser struct{}
+
+var rsyncProgressRe = regexp.MustCompile(`^\s*([\d,]+)\s+(\d+)%\s`)
+
+func (p *rsyncParser) ParseLine(line string) (Progress, bool) {
+	m := rsyncProgressRe.FindStringSubmatch(line)
+	if m == nil {
+		return Progress{}, false
+	}
+	current, err := parseNumber(m[1])
+	if err != nil {
+		return Progress{}, false
+	}
+	pct, _ := strconv.Atoi(m[2])
+	progress := Progress{Current: int64(current)}
+	if pct > 0 {
+		progress.Total = int64(current) * 100 / int64(pct)
+	}
+	return progress, true
+}
+
+// curlParser reads both the default progress meter, whose columns are
+// "% Total % Received % Xferd ...", and the "--progress-bar" percentage.
+type curlParser struct{}
+
+var curlBarRe = regexp.MustCompile(`^#*\s*(\d+(?:\.\d+)?)%\s*$`)
+
+func (p *curlParser) ParseLine(line string) (Progress, bool) {
+	if m := curlBarRe.FindStringSubmatch(line); m != nil {
+		pct, err := parseNumber(m[1])
+		if err != nil {
+			return Progress{}, false
+		}
+		return Progress{Percent: pct, IsPercent: true}, true
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return Progress{}, false
+	}
+	if _, err := strconv.Atoi(fields[0]); err != nil {
+		return Progress{}, false // Header lines
+	}
+	total, err := ParseSize(fields[1])
+	if err != nil {
+		return Progress{}, false
+	}
+	received, err := ParseSize(fields[3])
+	if err != nil {
+		return Progress{}, false
+	}
+	return Progress{Current: received, Total: total}, true
+}
+
+// ddParser reads the byte counts of "dd status=progress", in both the GNU
+// ("N bytes (...) copied") and BSD ("N bytes transferred") wordings. dd
+// does not know the total, so it has to come from the caller.
+type ddParser struct{}
+
+var ddProgressRe = regexp.MustCompile(`^\s*(\d+) bytes\b`)
+
+func (p *ddParser) ParseLine(line string) (Progress, bool) {
+	m := ddProgressRe.FindStringSubmatch(line)
+	if m == nil {
+		return Progress{}, false
+	}
+	current, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return Progress{}, false
+	}
+	return Progress{Current: current}, true
+}
+
+// ffmpegParser measures progress in milliseconds of output time. The total
+// comes from the "Duration:" line of the input banner, and the current
+// position from either "-progress" key=value blocks or the periodic stats
+// line on stderr.
+type ffmpegParser struct {
+	total   int64
+	sawUsec bool
+}
+
+var (
+	ffmpegDurationRe = regexp.MustCompile(`^\s*Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+	ffmpegStatsRe    = regexp.MustCompile(`(?:^|\s)time=(\d+):(\d+):(\d+(?:\.\d+)?)`)
+)
+
+func (p *ffmpegParser) ParseLine(line string) (Progress, bool) {
+	if m := ffmpegDurationRe.FindStringSubmatch(line); m != nil {
+		if p.total == 0 {
+			p.total = clockToMillis(m[1], m[2], m[3])
+		}
+		return Progress{}, false
+	}
+
+	if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok && !strings.Contains(key, " ") {
+		switch key {
+		case "out_time_us", "out_time_ms":
+			// Despite its name, out_time_ms is also in microseconds. Newer
+			// versions print both, so only the first one seen is used.
+			if key == "out_time_us" {
+				p.sawUsec = true
+			} else if p.sawUsec {
+				return Progress{}, false
+			}
+			usec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return Progress{}, false // "N/A" before the first frame
+			}
+			return Progress{Current: usec / 1000, Total: p.total}, true
+		case "progress":
+			if value == "end" && p.total > 0 {
+				return Progress{Current: p.total, Total: p.total}, true
+			}
+			return Progress{}, false
+		}
+	}
+
+	if m := ffmpegStatsRe.FindStringSubmatch(line); m != nil {
+		return Progress{Current: clockToMillis(m[1], m[2], m[3]), Total: p.total}, true
+	}
+	return Progress{}, false
+}
+
+func clockToMillis(hours, minutes, seconds string) int64 {
+	h, _ := strconv.ParseInt(hours, 10, 64)
+	m, _ := strconv.ParseInt(minutes, 10, 64)
+	s, _ := strconv.ParseFloat(seconds, 64)
+	return (h*3600+m*60)*1000 + int64(s*1000+0.5)
+}
+
+// wgetParser reads the percentage of both the "bar" and "dot" progress
+// styles, and converts it to bytes once the "Length:" header is known.
+type wgetParser struct {
+	total int64
+}
+
+var (
+	wgetLengthRe = regexp.MustCompile(`^Length: (\d+)`)
+	wgetBarRe    = regexp.MustCompile(`\s(\d+)%\[`)
+	wgetDotRe    = regexp.MustCompile(`^\s*\d+[KMG] [. ]+\s(\d+)%`)
+)
+
+func (p *wgetParser) ParseLine(line string) (Progress, bool) {
+	if m := wgetLengthRe.FindStringSubmatch(line); m != nil {
+		p.total, _ = strconv.ParseInt(m[1], 10, 64)
+		return Progress{}, false
+	}
+	m := wgetBarRe.FindStringSubmatch(line)
+	if m == nil {
+		m = wgetDotRe.FindStringSubmatch(line)
+	}
+	if m == nil {
+		return Progress{}, false
+	}
+	pct, _ := strconv.ParseInt(m[1], 10, 64)
+	if p.total > 0 {
+		return Progress{Current: p.total * pct / 100, Total: p.total}, true
+	}
+	return Progress{Percent: float64(pct), IsPercent: true}, true
+}
+
+// pipParser reads pip's download progress. Recent versions print
+// "5.2/12.3 MB" next to the bar; older ones only print the downloaded size,
+// with the total announced on the preceding "Downloading ... (12.3 MB)" line.
+type pipParser struct {
+	total int64
+}
+
+var (
+	pipDownloadingRe = regexp.MustCompile(`Downloading \S+ \(([\d.]+\s*[kMG]?B)\)`)
+	pipRatioRe       = regexp.MustCompile(`([\d.]+)/([\d.]+)\s*([kMG]?B)\b`)
+	pipLegacyRe      = regexp.MustCompile(`\|\s*([\d.]+\s*[kMG]?B)\s`)
+)
+
+func (p *pipParser) ParseLine(line string) (Progress, bool) {
+	if m := pipDownloadingRe.FindStringSubmatch(line); m != nil {
+		p.total, _ = ParseSize(m[1])
+		return Progress{}, false
+	}
+	if m := pipRatioRe.FindStringSubmatch(line); m != nil {
+		current, err := ParseSize(m[1] + m[3])
+		if err != nil {
+			return Progress{}, false
+		}
+		total, err := ParseSize(m[2] + m[3])
+		if err != nil {
+			return Progress{}, false
+		}
+		return Progress{Current: current, Total: total}, true
+	}
+	if m := pipLegacyRe.FindStringSubmatch(line); m != nil {
+		current, err := ParseSize(m[1])
+		if err != nil {
+			return Progress{}, false
+		}
+		return Progress{Current: current, Total: p.total}, true
+	}
+	return Progress{}, false
+}
@@ -0,0 +1,115 @@
+package pbar
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readingsFromFixture feeds a recorded tool output through a preset parser,
+// splitting it the same way the exec subcommand does.
+func readingsFromFixture(t *testing.T, preset, fixture string) []Progress {
+	t.Helper()
+	parser, err := NewPresetParser(preset)
+	if err != nil {
+		t.Fatalf("NewPresetParser(%q) failed: %v", preset, err)
+	}
+	f, err := os.Open(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	var readings []Progress
+	scanner := bufio.NewScanner(f)
+	scanner.Split(ScanProgressLines)
+	for scanner.Scan() {
+		if progress, ok := parser.ParseLine(scanner.Text()); ok {
+			readings = append(readings, progress)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return readings
+}
+
+func TestPresetParsers(t *testing.T) {
+	tests := []struct {
+		preset   string
+		fixture  string
+		expected []Progress
+	}{
+		{"rsync", "rsync.txt", []Progress{
+			{Current: 0},
+			{Current: 32768000, Total: 105703225},
+			{Current: 104857600, Total: 104857600},
+		}},
+		{"curl", "curl.txt", []Progress{
+			{Current: 0, Total: 0},
+			{Current: 524288, Total: 104857600},
+			{Current: 47395635, Total: 104857600},
+			{Current: 104857600, Total: 104857600},
+		}},
+		{"curl", "curl-progress-bar.txt", []Progress{
+			{Percent: 0, IsPercent: true},
+			{Percent: 30.1, IsPercent: true},
+			{Percent: 100, IsPercent: true},
+		}},
+		{"dd", "dd.txt", []Progress{
+			{Current: 52428800},
+			{Current: 157286400},
+			{Current: 209715200},
+		}},
+		{"dd", "dd-bsd.txt", []Progress{
+			{Current: 52428800},
+			{Current: 209715200},
+		}},
+		{"ffmpeg", "ffmpeg.txt", []Progress{
+			{Current: 4000, Total: 10000},
+			{Current: 8000, Total: 10000},
+			{Current: 10000, Total: 10000},
+			{Current: 10000, Total: 10000},
+		}},
+		{"wget", "wget.txt", []Progress{
+			{Current: 0, Total: 104857600},
+			{Current: 47185920, Total: 104857600},
+			{Current: 104857600, Total: 104857600},
+		}},
+		{"wget", "wget-dot.txt", []Progress{
+			{Percent: 0, IsPercent: true},
+			{Percent: 50, IsPercent: true},
+			{Percent: 100, IsPercent: true},
+		}},
+		{"pip", "pip.txt", []Progress{
+			{Current: 0, Total: 779100000},
+			{Current: 1600000, Total: 779100000},
+			{Current: 779100000, Total: 779100000},
+		}},
+		{"pip", "pip-legacy.txt", []Progress{
+			{Current: 3400000, Total: 13400000},
+			{Current: 13400000, Total: 13400000},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			actual := readingsFromFixture(t, tt.preset, tt.fixture)
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Expected readings\n%+v\ngot\n%+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNewPresetParser(t *testing.T) {
+	if _, err := NewPresetParser("scp"); err == nil {
+		t.Errorf("Expected an error for an unknown preset")
+	}
+	expected := []string{"curl", "dd", "ffmpeg", "pip", "rsync", "wget"}
+	if names := PresetNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected presets %v, got %v", expected, names)
+	}
+}
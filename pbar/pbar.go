@@ -1,11 +1,9 @@
 package pbar
 
 import (
-	"encoding/json"
+	"cmp"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -14,42 +12,8 @@ const (
 	maxThroughputHistorySize = 10
 	defaultStyle             = "classic"
 	defaultWidth             = 50
-	stateFilePrefix          = ".pbar."
-	stateFileSuffix          = ".state"
 )
 
-func getStateFile(instanceID string) string {
-	return filepath.Join(os.TempDir(), stateFilePrefix+instanceID+stateFileSuffix)
-}
-
-// SaveState saves the bar state to a file.
-func SaveState(b *Bar, instanceID string) error {
-	data, err := json.Marshal(b)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(getStateFile(instanceID), data, 0644)
-}
-
-// LoadState loads the bar state from a file.
-func LoadState(instanceID string) (*Bar, error) {
-	data, err := os.ReadFile(getStateFile(instanceID))
-	if err != nil {
-		return nil, err
-	}
-	var b Bar
-	err = json.Unmarshal(data, &b)
-	if err != nil {
-		return nil, err
-	}
-	return &b, nil
-}
-
-// DeleteState removes the state file.
-func DeleteState(instanceID string) error {
-	return os.Remove(getStateFile(instanceID))
-}
-
 var spinnerChars = []string{"|", "/", "-", "\\"}
 var brailleSpinnerChars = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 
@@ -75,28 +39,90 @@ var validStyles = map[string]bool{
 	"custom":          true,
 }
 
+// UnitBytes makes a bar display amounts and throughput as binary byte sizes.
+const UnitBytes = "bytes"
+
 // Bar represents a progress bar.
 type Bar struct {
-	Total             int       `json:"total"`
-	Current           int       `json:"current"`
-	PreviousCurrent   int       `json:"previous_current"`
-	Width             int       `json:"width"`
-	Style             string    `json:"style"`
-	ColorBar          string    `json:"color_bar"`
-	ColorText         string    `json:"color_text"`
-	Finished          bool      `json:"finished"`
-	StartTime         time.Time `json:"start_time"`
-	LastUpdateTime    time.Time `json:"last_update_time"`
-	ThroughputHistory []float64 `json:"throughput_history"`
-	CustomChars       string    `json:"custom_chars"`
-	Message           string    `json:"message"`
-	CompletionMessage string    `json:"completion_message"`
-	ShowElapsed       bool      `json:"show_elapsed"`
-	ShowThroughput    bool      `json:"show_throughput"`
-	ShowETA           bool      `json:"show_eta"`
-	SpinnerState      int       `json:"spinner_state"`
-	TestMode          bool      `json:"-"` // Not serialized
-	Managed           bool      `json:"-"` // True if the bar is managed by a Manager
+	Total             int64         `json:"total"`
+	Current           int64         `json:"current"`
+	PreviousCurrent   int64         `json:"previous_current"`
+	Width             int           `json:"width"`
+	LineWidth         int           `json:"line_width"` // Columns the whole line must fit in, 0 for no limit
+	AutoWidth         bool          `json:"auto_width"` // Grow the bar to fill LineWidth
+	Style             string        `json:"style"`
+	Unit              string        `json:"unit"` // What Current counts: "" for items, UnitBytes, or any other label
+	ColorBar          string        `json:"color_bar"`
+	ColorText         string        `json:"color_text"`
+	Finished          bool          `json:"finished"`
+	Failed            bool          `json:"failed"`
+	FailureMessage    string        `json:"failure_message"`
+	Paused            bool          `json:"paused"`
+	PausedAt          time.Time     `json:"paused_at"`
+	PausedDuration    time.Duration `json:"paused_duration"`
+	StartTime         time.Time     `json:"start_time"`
+	Deadline          time.Time     `json:"deadline"` // If set, the ETA is the time left until it
+	LastUpdateTime    time.Time     `json:"last_update_time"`
+	ThroughputHistory []float64     `json:"throughput_history"`
+	Format            string        `json:"format"` // Layout of the line, see ParseFormat; DefaultFormat if empty
+	CustomChars       string        `json:"custom_chars"`
+	Message           string        `json:"message"`
+	CompletionMessage string        `json:"completion_message"`
+	ShowElapsed       bool          `json:"show_elapsed"`
+	ShowThroughput    bool          `json:"show_throughput"`
+	ShowETA           bool          `json:"show_eta"`
+	SpinnerState      int           `json:"spinner_state"`
+	TestMode          bool          `json:"-"` // Not serialized
+	Managed           bool          `json:"-"` // True if the bar is managed by a Manager
+
+	// Columns, if set, lay out the line instead of Format.
+	Columns []Column `json:"-"`
+	format  *Format  // Compiled from Format by compiledFormat
+}
+
+// Elapsed returns the time spent on the bar so far, excluding paused periods.
+func (b *Bar) Elapsed() time.Duration {
+	if b.StartTime.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(b.StartTime) - b.PausedDuration
+	if b.Paused && !b.PausedAt.IsZero() {
+		elapsed -= time.Since(b.PausedAt)
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return elapsed
+}
+
+// Pause freezes the elapsed time, throughput and ETA of the bar.
+func (b *Bar) Pause() {
+	if b.Paused {
+		return
+	}
+	b.Paused = true
+	b.PausedAt = time.Now()
+}
+
+// Resume restarts the clocks of a paused bar. The paused period is excluded
+// from both the elapsed time and the next throughput sample.
+func (b *Bar) Resume() {
+	if !b.Paused {
+		return
+	}
+	if !b.PausedAt.IsZero() {
+		b.PausedDuration += time.Since(b.PausedAt)
+	}
+	b.Paused = false
+	b.PausedAt = time.Time{}
+	b.LastUpdateTime = time.Now()
+	b.PreviousCurrent = b.Current
+}
+
+// Fail marks the bar as failed with an optional reason.
+func (b *Bar) Fail(message string) {
+	b.Failed = true
+	b.FailureMessage = message
 }
 
 // Render generates the string representation of the progress bar.
@@ -106,41 +132,68 @@ func (b *Bar) Render() string {
 		b.Total = 0
 	}
 
-	percent := float64(b.Current) / float64(b.Total)
-
-	// Handle total being zero to prevent NaN or Inf
-	if b.Total == 0 {
-		if b.Current == 0 {
-			percent = 0 // 0/0 is 0%
-		} else {
-			percent = 1 // X/0 (X>0) is 100%
-		}
+	s := b.snapshot()
+	result := b.clipLine(b.fitLine(b.columns(), s))
+	if b.Failed || b.Finished || s.Indeterminate() || !b.Managed {
+		// Add carriage return for inline updates
+		result = "\r" + result + "\x1b[K"
 	}
 
-	if percent < 0 {
-		percent = 0
-	}
-	if percent > 1 {
-		percent = 1
+	if !b.Failed && !b.Finished {
+		b.SpinnerState++
+		// The next throughput sample counts only the progress made from here,
+		// however many times the bar is drawn between updates
+		if !b.Paused {
+			b.LastUpdateTime = time.Now()
+			b.PreviousCurrent = b.Current
+		}
 	}
+	return result
+}
 
-	percentString := fmt.Sprintf("%d%%", int(percent*100))
-
-	var metadataString string
-	var throughputStr, etaStr string
-
-	if !b.StartTime.IsZero() {
-		// Calculate elapsed time
-		elapsedTime := time.Since(b.StartTime)
-		elapsedTimeStr := formatDuration(elapsedTime)
-
-		// Calculate throughput and ETA only if not indeterminate and elapsed time is non-zero
-		isIndeterminate := b.Style == "spinner" || b.Style == "braille-spinner"
-		if !isIndeterminate && elapsedTime.Seconds() > 0 {
+// snapshot records a throughput sample and returns what the columns show.
+func (b *Bar) snapshot() Snapshot {
+	s := Snapshot{
+		Current:        b.Current,
+		Total:          b.Total,
+		Percent:        b.fraction(),
+		Unit:           b.Unit,
+		Style:          b.Style,
+		CustomChars:    b.CustomChars,
+		ColorBar:       b.ColorBar,
+		ColorText:      b.ColorText,
+		Width:          b.Width,
+		Message:        b.Message,
+		Finished:       b.Finished,
+		Failed:         b.Failed,
+		Paused:         b.Paused,
+		Frame:          b.SpinnerState,
+		ShowElapsed:    b.ShowElapsed,
+		ShowThroughput: b.ShowThroughput,
+		ShowETA:        b.ShowETA,
+	}
+	switch {
+	case b.Failed:
+		s.Status = cmp.Or(b.FailureMessage, "Task Failed!")
+	case b.Finished:
+		s.Status = cmp.Or(b.CompletionMessage, "Task Complete!")
+		s.Percent = 1
+	}
+	if b.StartTime.IsZero() {
+		return s
+	}
+	s.Started = true
+	s.Elapsed = b.Elapsed()
+
+	// Calculate throughput only if not failed and elapsed time is non-zero. Indeterminate
+	// bars only have a meaningful throughput when they count something in a known unit.
+	if (!s.Indeterminate() || b.Unit != "") && !b.Failed && s.Elapsed.Seconds() > 0 {
+		// A paused bar keeps its history frozen instead of sampling zeros
+		if !b.Paused || len(b.ThroughputHistory) == 0 {
 			deltaCurrent := b.Current - b.PreviousCurrent
 
 			var deltaTime float64
-			if !b.LastUpdateTime.IsZero() {
+			if !b.LastUpdateTime.IsZero() && !b.Paused {
 				deltaTime = time.Since(b.LastUpdateTime).Seconds()
 			}
 
@@ -156,180 +209,106 @@ func (b *Bar) Render() string {
 			if len(b.ThroughputHistory) > maxThroughputHistorySize { // Keep last 10 samples
 				b.ThroughputHistory = b.ThroughputHistory[1:]
 			}
-
-			// Calculate average throughput
-			var totalThroughput float64
-			for _, t := range b.ThroughputHistory {
-				totalThroughput += t
-			}
-			averageThroughput := totalThroughput / float64(len(b.ThroughputHistory))
-
-			if b.ShowThroughput {
-				throughputStr = fmt.Sprintf("%.2f it/s", averageThroughput)
-			}
-
-			// Calculate ETA based on average throughput
-			remainingItems := float64(b.Total - b.Current)
-			if remainingItems < 0 {
-				remainingItems = 0
-			}
-
-			if b.ShowETA {
-				if remainingItems == 0 {
-					etaStr = "ETA 0s"
-				} else if averageThroughput > 0 {
-					eta := time.Duration(remainingItems / averageThroughput * float64(time.Second))
-					etaStr = fmt.Sprintf("ETA %s", formatDuration(eta))
-				} else {
-					etaStr = "ETA Inf"
-				}
-			}
 		}
-		var metadataParts []string
-		if b.ShowElapsed {
-			metadataParts = append(metadataParts, fmt.Sprintf("Elapsed %s", elapsedTimeStr))
+		s.RateKnown = true
+		s.Rate = b.averageThroughput()
+		if !b.Deadline.IsZero() {
+			s.HasDeadline = true
+			s.Remaining = max(time.Until(b.Deadline), 0)
 		}
-		if b.ShowThroughput && throughputStr != "" {
-			metadataParts = append(metadataParts, throughputStr)
-		}
-		if b.ShowETA && etaStr != "" {
-			metadataParts = append(metadataParts, etaStr)
-		}
-		if len(metadataParts) > 0 {
-			metadataString = " " + strings.Join(metadataParts, " ")
-		}
-	}
-
-	if b.Message != "" {
-		metadataString = fmt.Sprintf("%s %s", metadataString, b.Message)
-	}
-
-	if b.Finished {
-		finalFinishedMessage := "Task Complete!" // Default message
-		if b.CompletionMessage != "" {
-			finalFinishedMessage = b.CompletionMessage
-		}
-		result := fmt.Sprintf("[✔] 100%% %s%s", finalFinishedMessage, metadataString)
-		result = "\r" + result + "\x1b[K"
-		return result
 	}
+	return s
+}
 
-	isIndeterminate := b.Style == "spinner" || b.Style == "braille-spinner"
-	if isIndeterminate {
-		var char string
-		switch b.Style {
-		case "braille-spinner":
-			char = brailleSpinnerChars[b.SpinnerState%len(brailleSpinnerChars)]
-		default:
-			char = spinnerChars[b.SpinnerState%len(spinnerChars)]
-		}
-		b.SpinnerState++
-		result := fmt.Sprintf("[%s]%s", char, metadataString)
-		if b.ColorText != "" {
-			result = fmt.Sprintf("[%s%s%s]%s", b.ColorText, char, "\x1b[0m", metadataString)
+// fraction returns the part of the total done, from 0 to 1.
+func (b *Bar) fraction() float64 {
+	// Handle total being zero to prevent NaN or Inf
+	if b.Total <= 0 {
+		if b.Current == 0 {
+			return 0 // 0/0 is 0%
 		}
-		result = "\r" + result + "\x1b[K"
-		return result
+		return 1 // X/0 (X>0) is 100%
 	}
+	return min(max(float64(b.Current)/float64(b.Total), 0), 1)
+}
 
-	style := b.Style
-	if _, ok := validStyles[style]; !ok {
-		style = defaultStyle
+// columns returns the columns of the line: Columns if set, or else those
+// of the bar's Format.
+func (b *Bar) columns() []Column {
+	if b.Columns != nil {
+		return b.Columns
 	}
+	return b.compiledFormat().columns
+}
 
-	var barString string
-	switch style {
-	case "spinner":
-		char := spinnerChars[b.SpinnerState%len(spinnerChars)]
-		b.SpinnerState++
-		barString = fmt.Sprintf("[%s]", char)
-		if b.ColorText != "" {
-			barString = fmt.Sprintf("[%s%s%s]", b.ColorText, char, "\x1b[0m")
+// compiledFormat returns the layout of the bar, compiling b.Format the first
+// time it is used. An invalid Format, which Validate reports, falls back to
+// DefaultFormat.
+func (b *Bar) compiledFormat() *Format {
+	if b.Format == "" {
+		return defaultFormat
+	}
+	if b.format == nil || b.format.source != b.Format {
+		f, err := ParseFormat(b.Format)
+		if err != nil {
+			f = &Format{source: b.Format, columns: defaultFormat.columns}
 		}
-	case "braille-spinner":
-		char := brailleSpinnerChars[b.SpinnerState%len(brailleSpinnerChars)]
-		b.SpinnerState++
-		barString = fmt.Sprintf("[%s]", char)
-		if b.ColorText != "" {
-			barString = fmt.Sprintf("[%s%s%s]", b.ColorText, char, "\x1b[0m")
-		}
-	case "block":
-		barString = b.renderBar("█", " ", b.ColorBar)
-	case "classic":
-		barString = b.renderBar("#", "-", b.ColorBar)
-	case "arrow":
-		barString = b.renderArrowBar(b.ColorBar)
-	case "braille":
-		barString = b.renderBrailleBar(b.ColorBar)
-	case "custom":
-		filledChar := "#" // Default
-		emptyChar := "-"  // Default
-
-		if len(b.CustomChars) > 0 {
-			filledChar = string(b.CustomChars[0])
-			if len(b.CustomChars) > 1 {
-				emptyChar = string(b.CustomChars[1])
-			} else {
-				emptyChar = filledChar // If only one char, use it for both
-			}
-		}
-		barString = b.renderBar(filledChar, emptyChar, b.ColorBar)
-	}
-
-	if b.ColorText != "" {
-		percentString = fmt.Sprintf("%s%s%s", b.ColorText, percentString, "\x1b[0m") // Use reset code directly
+		b.format = f
 	}
-
-	result := fmt.Sprintf("%s %s%s", barString, percentString, metadataString)
-
-	if !b.Managed {
-		// Add carriage return for inline updates
-		result = "\r" + result + "\x1b[K"
-	}
-
-	b.LastUpdateTime = time.Now()
-	return result
+	return b.format
 }
 
-func (b *Bar) renderBar(filledChar, emptyChar, colorCode string) string {
-	// Ensure Total is not negative
-	if b.Total < 0 {
-		b.Total = 0
-	}
+// customChars splits the characters of the custom style into the fill, the
+// empty cells and an optional head drawn at the tip of the fill. A single
+// character is used for both the fill and the empty cells.
+func customChars(chars string) (filled, empty, head string) {
+	clusters := graphemes(chars)
+	switch len(clusters) {
+	case 0:
+		return "#", "-", ""
+	case 1:
+		return clusters[0], clusters[0], ""
+	case 2:
+		return clusters[0], clusters[1], ""
+	}
+	return clusters[0], clusters[1], clusters[2]
+}
 
+// renderBar draws a bar width columns wide. Characters wider than one
+// column fill as many columns as they occupy, and any column left over is
+// padded with a space so the bar keeps its width.
+func renderBar(width int, percent float64, filledChar, emptyChar, headChar, colorCode string) string {
 	// If width is 0 or negative, return an empty bar
-	if b.Width <= 0 {
+	if width <= 0 {
 		return "[]"
 	}
 
-	percent := float64(b.Current) / float64(b.Total)
-
-	// Handle total being zero to prevent NaN or Inf
-	if b.Total == 0 {
-		if b.Current == 0 {
-			percent = 0 // 0/0 is 0%
-		} else {
-			percent = 1 // X/0 (X>0) is 100%
-		}
+	filledWidth := int(percent * float64(width))
+	if width == 1 && percent > 0 { // Special handling for width 1 and non-zero progress
+		filledWidth = 1
 	}
 
-	if percent < 0 {
-		percent = 0
-	}
-	if percent > 1 {
-		percent = 1
-	}
+	fillCharWidth := max(displayWidth(filledChar), 1)
+	emptyCharWidth := max(displayWidth(emptyChar), 1)
+	headWidth := displayWidth(headChar)
 
-	filledWidth := int(percent * float64(b.Width))
-	if b.Width == 1 && percent > 0 { // Special handling for width 1 and non-zero progress
-		filledWidth = 1
+	var sb strings.Builder
+	used := 0
+	if headChar != "" && filledWidth > 0 && filledWidth < width && headWidth <= filledWidth {
+		n := (filledWidth - headWidth) / fillCharWidth
+		sb.WriteString(strings.Repeat(filledChar, n))
+		sb.WriteString(headChar)
+		used = n*fillCharWidth + headWidth
+	} else {
+		n := filledWidth / fillCharWidth
+		sb.WriteString(strings.Repeat(filledChar, n))
+		used = n * fillCharWidth
 	}
-	emptyWidth := b.Width - filledWidth
-
-	filled := strings.Repeat(filledChar, filledWidth)
-	empty := strings.Repeat(emptyChar, emptyWidth)
+	n := (width - used) / emptyCharWidth
+	sb.WriteString(strings.Repeat(emptyChar, n))
+	sb.WriteString(strings.Repeat(" ", width-used-n*emptyCharWidth))
 
-	barContent := fmt.Sprintf("[%s%s]", filled, empty)
+	barContent := "[" + sb.String() + "]"
 
 	if colorCode != "" {
 		return fmt.Sprintf("%s%s%s", colorCode, barContent, "\x1b[0m") // Use reset code directly
@@ -337,36 +316,14 @@ func (b *Bar) renderBar(filledChar, emptyChar, colorCode string) string {
 	return barContent
 }
 
-func (b *Bar) renderArrowBar(colorCode string) string {
-	// Ensure Total is not negative
-	if b.Total < 0 {
-		b.Total = 0
-	}
-
+func renderArrowBar(width int, percent float64, colorCode string) string {
 	// If width is 0 or negative, return an empty bar
-	if b.Width <= 0 {
+	if width <= 0 {
 		return "[]"
 	}
 
-	percent := float64(b.Current) / float64(b.Total)
-	// Handle total being zero to prevent NaN or Inf
-	if b.Total == 0 {
-		if b.Current == 0 {
-			percent = 0 // 0/0 is 0%
-		} else {
-			percent = 1 // X/0 (X>0) is 100%
-		}
-	}
-
-	if percent < 0 {
-		percent = 0
-	}
-	if percent > 1 {
-		percent = 1
-	}
-
-	filledWidth := int(percent * float64(b.Width))
-	emptyWidth := b.Width - filledWidth
+	filledWidth := int(percent * float64(width))
+	emptyWidth := width - filledWidth
 
 	var filled string
 	if filledWidth > 0 {
@@ -386,42 +343,20 @@ func (b *Bar) renderArrowBar(colorCode string) string {
 	return barContent
 }
 
-func (b *Bar) renderBrailleBar(colorCode string) string {
-	// Ensure Total is not negative
-	if b.Total < 0 {
-		b.Total = 0
-	}
-
+func renderBrailleBar(width int, percent float64, colorCode string) string {
 	// If width is 0 or negative, return an empty bar
-	if b.Width <= 0 {
+	if width <= 0 {
 		return "[]"
 	}
 
-	percent := float64(b.Current) / float64(b.Total)
-	// Handle total being zero to prevent NaN or Inf
-	if b.Total == 0 {
-		if b.Current == 0 {
-			percent = 0 // 0/0 is 0%
-		} else {
-			percent = 1 // X/0 (X>0) is 100%
-		}
-	}
-
-	if percent < 0 {
-		percent = 0
-	}
-	if percent > 1 {
-		percent = 1
-	}
-
 	// Calculate total braille units in the bar
-	totalBrailleUnits := b.Width * (len(brailleChars) - 1)
+	totalBrailleUnits := width * (len(brailleChars) - 1)
 	filledBrailleUnits := int(percent * float64(totalBrailleUnits))
 
 	var barContentBuilder strings.Builder
 	barContentBuilder.WriteString("[")
 
-	for i := 0; i < b.Width; i++ {
+	for i := 0; i < width; i++ {
 		// Calculate units for the current character cell
 		currentCellUnits := filledBrailleUnits - (i * (len(brailleChars) - 1))
 
@@ -468,11 +403,16 @@ func (b *Bar) Validate() error {
 	if !validStyles[b.Style] && b.Style != "braille-spinner" {
 		return fmt.Errorf("invalid style: %s", b.Style)
 	}
+	if b.Format != "" {
+		if _, err := ParseFormat(b.Format); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 type ProgressBar interface {
 	Render() string
-	Update(current int)
+	Update(current int64)
 	Finish()
 }
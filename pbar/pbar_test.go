@@ -380,6 +380,45 @@ func TestCustomBar(t *testing.T) {
 			t.Errorf("Expected '%s', but got '%s'", expected, actual)
 		}
 	})
+
+	tests := []struct {
+		name     string
+		chars    string
+		current  int64
+		expected string
+	}{
+		{"multi-byte characters", "█░", 50, "[█████░░░░░]"},
+		{"combining characters", "e\u0301o", 30, "[e\u0301e\u0301e\u0301ooooooo]"},
+		{"wide characters", "🟩⬜", 50, "[🟩🟩⬜⬜⬜]"},
+		{"wide fill and narrow empty", "🟩.", 50, "[🟩🟩......]"},
+		{"wide fill at an odd column", "🟩⬜", 30, "[🟩⬜⬜⬜⬜]"},
+		{"wide empty at an odd column", "#⬜", 50, "[#####⬜⬜ ]"},
+		{"emoji sequences", "👍🏽👎\ufe0f", 40, "[👍🏽👍🏽👎\ufe0f👎\ufe0f👎\ufe0f]"},
+		{"head", "=->", 50, "[====>-----]"},
+		{"head when empty", "=->", 0, "[----------]"},
+		{"head when full", "=->", 100, "[==========]"},
+		{"wide head", "━─🚀", 50, "[━━━🚀─────]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bar := &Bar{
+				Total:       100,
+				Current:     tt.current,
+				Width:       10,
+				Style:       "custom",
+				CustomChars: tt.chars,
+				Managed:     true,
+			}
+			actual := bar.Render()
+			barString := actual[:strings.LastIndex(actual, "]")+1]
+			if barString != tt.expected {
+				t.Errorf("Expected '%s', but got '%s'", tt.expected, barString)
+			}
+			if width := displayWidth(barString); width != 12 {
+				t.Errorf("Expected the bar to be 12 columns wide, got %d", width)
+			}
+		})
+	}
 }
 
 func TestBarEdgeCases(t *testing.T) {
@@ -614,6 +653,31 @@ func TestThroughputHistory(t *testing.T) {
 			t.Errorf("Throughput history exceeded limit of 10, got %d", len(bar.ThroughputHistory))
 		}
 	})
+
+	t.Run("each sample counts the progress since the previous render", func(t *testing.T) {
+		bar := &Bar{Total: 100, Width: 10, StartTime: time.Now().Add(-10 * time.Second)}
+		bar.Render()
+
+		bar.Current = 50
+		bar.LastUpdateTime = time.Now().Add(-time.Second) // Pretend the last render was a second ago
+		bar.Render()
+
+		// A manager redraws every bar after each update, so a bar is often
+		// rendered again without having moved
+		bar.LastUpdateTime = time.Now().Add(-time.Second)
+		bar.Render()
+
+		history := bar.ThroughputHistory
+		if len(history) != 3 {
+			t.Fatalf("Expected 3 samples, got %v", history)
+		}
+		if history[1] < 45 || history[1] > 50 {
+			t.Errorf("Expected about 50 it/s after moving by 50 in a second, got %v", history[1])
+		}
+		if history[2] != 0 {
+			t.Errorf("Expected 0 it/s for a render without progress, got %v", history[2])
+		}
+	})
 }
 
 func TestLongDurations(t *testing.T) {
@@ -633,3 +697,143 @@ func TestLongDurations(t *testing.T) {
 		}
 	})
 }
+
+func TestFailedState(t *testing.T) {
+	t.Run("renders a failed bar with default message", func(t *testing.T) {
+		bar := &Bar{Total: 100, Current: 40, Width: 10, Failed: true}
+		expected := "\r\x1b[31m[✘]\x1b[0m 40% Task Failed!\x1b[K"
+		actual := bar.Render()
+		if actual != expected {
+			t.Errorf("Expected '%s', but got '%s'", expected, actual)
+		}
+	})
+
+	t.Run("renders a failed bar with a reason", func(t *testing.T) {
+		bar := &Bar{Total: 100, Current: 40, Width: 10}
+		bar.Fail("disk full")
+		expected := "\r\x1b[31m[✘]\x1b[0m 40% disk full\x1b[K"
+		actual := bar.Render()
+		if actual != expected {
+			t.Errorf("Expected '%s', but got '%s'", expected, actual)
+		}
+	})
+
+	t.Run("omits the percentage for spinners", func(t *testing.T) {
+		bar := &Bar{Style: "spinner", Failed: true, FailureMessage: "boom"}
+		expected := "\r\x1b[31m[✘]\x1b[0m boom\x1b[K"
+		actual := bar.Render()
+		if actual != expected {
+			t.Errorf("Expected '%s', but got '%s'", expected, actual)
+		}
+	})
+}
+
+func TestPauseResume(t *testing.T) {
+	t.Run("paused time is excluded from elapsed time", func(t *testing.T) {
+		bar := &Bar{Total: 100, Current: 50, Width: 10, StartTime: time.Now().Add(-5 * time.Second)}
+		bar.Pause()
+		bar.PausedAt = bar.PausedAt.Add(-3 * time.Second) // Pretend we paused 3 seconds ago
+		if elapsed := bar.Elapsed(); elapsed > 2100*time.Millisecond || elapsed < 1900*time.Millisecond {
+			t.Errorf("Expected elapsed time around 2s while paused, got %s", elapsed)
+		}
+
+		bar.Resume()
+		if elapsed := bar.Elapsed(); elapsed > 2100*time.Millisecond || elapsed < 1900*time.Millisecond {
+			t.Errorf("Expected elapsed time around 2s after resume, got %s", elapsed)
+		}
+	})
+
+	t.Run("paused bar freezes its throughput history", func(t *testing.T) {
+		bar := &Bar{
+			Total:             100,
+			Current:           50,
+			Width:             10,
+			StartTime:         time.Now().Add(-5 * time.Second),
+			ThroughputHistory: []float64{10, 10},
+			ShowETA:           true,
+			ShowThroughput:    true,
+		}
+		bar.Pause()
+		actual := bar.Render()
+		if len(bar.ThroughputHistory) != 2 {
+			t.Errorf("Expected history to stay at 2 samples, got %d", len(bar.ThroughputHistory))
+		}
+		if !strings.Contains(actual, "10.00 it/s ETA 5s Paused") {
+			t.Errorf("Expected frozen throughput and ETA, got '%s'", actual)
+		}
+	})
+}
+
+func TestUnits(t *testing.T) {
+	t.Run("byte bars show byte throughput", func(t *testing.T) {
+		bar := &Bar{
+			Total:             8 << 30,
+			Current:           4 << 30,
+			Width:             10,
+			Unit:              UnitBytes,
+			StartTime:         time.Now().Add(-time.Second),
+			ThroughputHistory: []float64{2 << 20},
+			Paused:            true, // Freeze the history so the rate is predictable
+			ShowThroughput:    true,
+		}
+		actual := bar.Render()
+		if !strings.Contains(actual, "[#####-----] 50% 2.00 MiB/s") {
+			t.Errorf("Expected byte throughput, got '%s'", actual)
+		}
+	})
+
+	t.Run("spinners with a unit show the amount and throughput", func(t *testing.T) {
+		bar := &Bar{
+			Style:             "spinner",
+			Current:           3 << 20,
+			Unit:              UnitBytes,
+			StartTime:         time.Now().Add(-time.Second),
+			ThroughputHistory: []float64{1 << 20},
+			Paused:            true,
+			ShowThroughput:    true,
+			ShowETA:           true,
+		}
+		actual := bar.Render()
+		if !strings.Contains(actual, "[|] 3.00 MiB 1.00 MiB/s Paused") {
+			t.Errorf("Expected amount and throughput without ETA, got '%s'", actual)
+		}
+	})
+
+	t.Run("other units are used as labels", func(t *testing.T) {
+		if rate := (Snapshot{Unit: "lines"}).FormatRate(12.5); rate != "12.50 lines/s" {
+			t.Errorf("Expected '12.50 lines/s', got '%s'", rate)
+		}
+	})
+}
+
+func TestSixtyFourBitCounters(t *testing.T) {
+	bar := &Bar{
+		Total:   6 << 40, // 6 TiB
+		Current: 3 << 40,
+		Width:   10,
+	}
+	expected := "\r[#####-----] 50%\x1b[K"
+	actual := bar.Render()
+	if actual != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, actual)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	bar := &Bar{
+		Total:     300000,
+		Current:   60000,
+		Width:     10,
+		StartTime: time.Now().Add(-time.Minute),
+		Deadline:  time.Now().Add(4*time.Minute + 500*time.Millisecond),
+		ShowETA:   true,
+	}
+	if actual := bar.Render(); !strings.Contains(actual, " Remaining 4m0s") || strings.Contains(actual, "ETA") {
+		t.Errorf("Expected the time left until the deadline instead of an ETA, got '%s'", actual)
+	}
+
+	bar.Deadline = time.Now().Add(-time.Second)
+	if actual := bar.Render(); !strings.Contains(actual, " Remaining 0s") {
+		t.Errorf("Expected no time left after the deadline, got '%s'", actual)
+	}
+}
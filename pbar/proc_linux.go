@@ -0,0 +1,91 @@
+package pbar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FilePosition describes how far a process has got through an open file.
+type FilePosition struct {
+	FD   int
+	Path string
+	Pos  int64 // Current offset of the descriptor
+	Size int64 // Size of the file it refers to
+}
+
+// ReadFilePosition reads the offset of descriptor fd in process pid from
+// /proc/<pid>/fdinfo, together with the path and size of the file.
+func ReadFilePosition(pid, fd int) (FilePosition, error) {
+	fdPath := filepath.Join("/proc", strconv.Itoa(pid), "fd", strconv.Itoa(fd))
+	target, err := os.Readlink(fdPath)
+	if err != nil {
+		return FilePosition{}, err
+	}
+	info, err := os.Stat(fdPath)
+	if err != nil {
+		return FilePosition{}, err
+	}
+
+	f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "fdinfo", strconv.Itoa(fd)))
+	if err != nil {
+		return FilePosition{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "pos:")
+		if !ok {
+			continue
+		}
+		pos, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return FilePosition{}, fmt.Errorf("invalid position in fdinfo: %w", err)
+		}
+		return FilePosition{FD: fd, Path: target, Pos: pos, Size: info.Size()}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return FilePosition{}, err
+	}
+	return FilePosition{}, errors.New("no position in fdinfo")
+}
+
+// LargestOpenFile returns the descriptor of the largest regular file that
+// process pid has open, which is usually the input it is working through.
+func LargestOpenFile(pid int) (int, error) {
+	fdDir := filepath.Join("/proc", strconv.Itoa(pid), "fd")
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return -1, err
+	}
+
+	best, bestSize := -1, int64(-1)
+	for _, entry := range entries {
+		fd, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(fdDir, entry.Name()))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.Size() > bestSize {
+			best, bestSize = fd, info.Size()
+		}
+	}
+	if best < 0 {
+		return -1, fmt.Errorf("process %d has no regular files open", pid)
+	}
+	return best, nil
+}
+
+// ProcessExists reports whether a process with the given pid is running.
+func ProcessExists(pid int) bool {
+	_, err := os.Stat(filepath.Join("/proc", strconv.Itoa(pid)))
+	return err == nil
+}
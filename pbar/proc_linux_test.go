@@ -0,0 +1,68 @@
+package pbar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFilePosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.bin")
+	if err := os.WriteFile(path, make([]byte, 4096), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open input: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(1000, 0); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+
+	position, err := ReadFilePosition(os.Getpid(), int(f.Fd()))
+	if err != nil {
+		t.Fatalf("ReadFilePosition failed: %v", err)
+	}
+	if position.Pos != 1000 || position.Size != 4096 || position.Path != path {
+		t.Errorf("Unexpected position %+v", position)
+	}
+
+	if _, err := ReadFilePosition(os.Getpid(), 1<<20); err == nil {
+		t.Errorf("Expected an error for a descriptor that is not open")
+	}
+}
+
+func TestLargestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	small, err := os.Create(filepath.Join(dir, "small"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer small.Close()
+	large, err := os.Create(filepath.Join(dir, "large"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer large.Close()
+	if err := large.Truncate(1 << 40); err != nil { // Sparse, so it costs no disk space
+		t.Fatalf("Truncate failed: %v", err)
+	}
+
+	fd, err := LargestOpenFile(os.Getpid())
+	if err != nil {
+		t.Fatalf("LargestOpenFile failed: %v", err)
+	}
+	if fd != int(large.Fd()) {
+		t.Errorf("Expected descriptor %d, got %d", large.Fd(), fd)
+	}
+}
+
+func TestProcessExists(t *testing.T) {
+	if !ProcessExists(os.Getpid()) {
+		t.Errorf("Expected the test process to exist")
+	}
+	if ProcessExists(1 << 30) {
+		t.Errorf("Expected an impossible pid not to exist")
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package pbar
+
+import "errors"
+
+// FilePosition describes how far a process has got through an open file.
+type FilePosition struct {
+	FD   int
+	Path string
+	Pos  int64 // Current offset of the descriptor
+	Size int64 // Size of the file it refers to
+}
+
+var errNoProcfs = errors.New("tracking another process requires /proc, which is only available on Linux")
+
+// ReadFilePosition is only supported on Linux.
+func ReadFilePosition(pid, fd int) (FilePosition, error) {
+	return FilePosition{}, errNoProcfs
+}
+
+// LargestOpenFile is only supported on Linux.
+func LargestOpenFile(pid int) (int, error) {
+	return -1, errNoProcfs
+}
+
+// ProcessExists is only supported on Linux and always reports false elsewhere.
+func ProcessExists(pid int) bool {
+	return false
+}
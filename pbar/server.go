@@ -0,0 +1,149 @@
+package pbar
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// OpAttach is sent by a viewer as the only line of its connection to a
+// Server. Instead of replies, it gets the updates that recreate every bar,
+// followed by every update the Server applies from then on.
+const OpAttach = "attach"
+
+// viewerBuffer is the number of updates a viewer may fall behind before the
+// Server detaches it.
+const viewerBuffer = 1024
+
+// Server applies updates received over stream connections, such as a Unix
+// socket, to a Manager. Each connection carries JSON-encoded Updates, one
+// per line, and gets a Reply line back for each of them.
+type Server struct {
+	Manager *Manager
+}
+
+// Reply acknowledges an update received by a Server.
+type Reply struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Serve accepts connections on l until it is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn applies the updates read from conn until the client hangs up.
+func (s *Server) ServeConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var reply Reply
+		var update Update
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			s.Manager.RecordParseError()
+			reply.Error = fmt.Sprintf("invalid update: %v", err)
+		} else if update.Op == OpAttach {
+			s.serveViewer(conn)
+			return
+		} else if err := s.Manager.UpdateBar(update); err != nil {
+			reply.Error = err.Error()
+		}
+		if err := encoder.Encode(reply); err != nil {
+			return
+		}
+	}
+}
+
+// serveViewer streams the state of the bars to conn until the viewer hangs
+// up or falls behind.
+func (s *Server) serveViewer(conn net.Conn) {
+	snapshot, updates, cancel := s.Manager.Subscribe(viewerBuffer)
+	defer cancel()
+	go func() {
+		// Viewers send nothing more, so a read only ends when they hang up
+		io.Copy(io.Discard, conn)
+		cancel()
+	}()
+
+	encoder := json.NewEncoder(conn)
+	for _, update := range snapshot {
+		if err := encoder.Encode(update); err != nil {
+			return
+		}
+	}
+	for update := range updates {
+		if err := encoder.Encode(update); err != nil {
+			return
+		}
+	}
+	// The updates also end when the viewer hangs up
+	if cancel() {
+		message := "pbar: detached because this viewer fell behind"
+		encoder.Encode(Update{Op: OpLog, Message: &message})
+	}
+}
+
+// SendUpdates sends updates to the Server listening on the Unix socket at
+// path and waits for each of them to be applied.
+func SendUpdates(path string, updates ...Update) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+	for _, update := range updates {
+		if err := encoder.Encode(update); err != nil {
+			return err
+		}
+		var reply Reply
+		if err := decoder.Decode(&reply); err != nil {
+			return fmt.Errorf("no reply from %s: %w", path, err)
+		}
+		if reply.Error != "" {
+			return errors.New(reply.Error)
+		}
+	}
+	return nil
+}
+
+// Attach connects to the Server listening on the Unix socket at path as a
+// viewer, and passes every update it streams to handle until the Server
+// goes away.
+func Attach(path string, handle func(Update)) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(Update{Op: OpAttach}); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(conn)
+	for {
+		var update Update
+		if err := decoder.Decode(&update); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		handle(update)
+	}
+}
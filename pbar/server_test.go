@@ -0,0 +1,132 @@
+package pbar
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// startServer serves a new Manager on a Unix socket in a temporary directory.
+func startServer(t *testing.T) (*Manager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pbar.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", path, err)
+	}
+	m := NewManager()
+	go (&Server{Manager: m}).Serve(l)
+	t.Cleanup(func() { l.Close() })
+	return m, path
+}
+
+func TestServer(t *testing.T) {
+	t.Run("updates from several clients reach one manager", func(t *testing.T) {
+		m, path := startServer(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := SendUpdates(path, Update{ID: "build", Delta: 1, Total: int64Ptr(10)}); err != nil {
+					t.Errorf("SendUpdates failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if err := SendUpdates(path, Update{ID: "test", Current: int64Ptr(3)}, Update{ID: "test", Message: stringPtr("running")}); err != nil {
+			t.Fatalf("SendUpdates failed: %v", err)
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if got := m.bars["build"].Current; got != 10 {
+			t.Errorf("Expected every delta to be applied, got current %d", got)
+		}
+		if bar := m.bars["test"]; bar.Current != 3 || bar.Message != "running" {
+			t.Errorf("Expected both updates on one connection to be applied, got %+v", bar)
+		}
+	})
+
+	t.Run("errors are returned to the client", func(t *testing.T) {
+		_, path := startServer(t)
+		if err := SendUpdates(path, Update{ID: "a", Op: "explode"}); err == nil {
+			t.Errorf("Expected an error for an unknown op")
+		}
+	})
+}
+
+func TestAttach(t *testing.T) {
+	m, path := startServer(t)
+	SendUpdates(path, Update{ID: "build", Current: int64Ptr(40), Total: int64Ptr(100)})
+
+	viewer := NewManager()
+	received := make(chan struct{}, 10)
+	go Attach(path, func(update Update) {
+		viewer.UpdateBar(update)
+		received <- struct{}{}
+	})
+	<-received // Snapshot
+
+	// The subscription is in place once the snapshot arrives
+	SendUpdates(path, Update{ID: "build", Delta: 10}, Update{ID: "test", Op: OpFail, Message: stringPtr("3 failed")})
+	<-received
+	<-received
+
+	viewer.mu.Lock()
+	defer viewer.mu.Unlock()
+	if got := viewer.bars["build"].Current; got != 50 {
+		t.Errorf("Expected the viewer to follow the daemon to 50, got %d", got)
+	}
+	if bar := viewer.bars["test"]; bar == nil || !bar.Failed {
+		t.Errorf("Expected the viewer to see the failed bar, got %+v", bar)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.subs) != 1 {
+		t.Errorf("Expected one subscriber, got %d", len(m.subs))
+	}
+}
+
+func TestServeViewerFallingBehind(t *testing.T) {
+	m := NewManager()
+	m.UpdateBar(Update{ID: "build", Current: int64Ptr(1), Total: int64Ptr(100)})
+
+	// A pipe has no buffer, so the viewer falls behind as soon as it stops
+	// reading
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		(&Server{Manager: m}).serveViewer(server)
+		server.Close()
+	}()
+	decoder := json.NewDecoder(client)
+	var update Update
+	if err := decoder.Decode(&update); err != nil {
+		t.Fatalf("Failed to read the snapshot: %v", err)
+	}
+
+	for i := 0; i < 2*viewerBuffer; i++ {
+		m.UpdateBar(Update{ID: "build", Delta: 1})
+	}
+	var last Update
+	for {
+		var update Update
+		if err := decoder.Decode(&update); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			break
+		}
+		last = update
+	}
+	if last.Op != OpLog || last.Message == nil || *last.Message != "pbar: detached because this viewer fell behind" {
+		t.Errorf("Expected the viewer to be told why it was detached, got %+v", last)
+	}
+}
@@ -0,0 +1,65 @@
+package pbar
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps unit suffixes to their multipliers. As in GNU coreutils,
+// single letters and IEC suffixes are powers of 1024, while "kB", "MB", ...
+// are powers of 1000.
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kib": 1 << 10,
+	"kb":  1e3,
+	"m":   1 << 20,
+	"mib": 1 << 20,
+	"mb":  1e6,
+	"g":   1 << 30,
+	"gib": 1 << 30,
+	"gb":  1e9,
+	"t":   1 << 40,
+	"tib": 1 << 40,
+	"tb":  1e12,
+}
+
+// ParseSize parses a byte size such as "4096", "4G", "1.5 MiB" or "12.3 MB".
+func ParseSize(s string) (int64, error) {
+	trimmed := strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	i := 0
+	for i < len(trimmed) && (trimmed[i] >= '0' && trimmed[i] <= '9' || trimmed[i] == '.') {
+		i++
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	value, err := strconv.ParseFloat(trimmed[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	multiplier, ok := sizeUnits[strings.ToLower(strings.TrimSpace(trimmed[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit in '%s'", s)
+	}
+	return int64(math.Round(value * multiplier)), nil
+}
+
+// FormatBytes formats a byte count with binary (IEC) units, e.g. "12.34 MiB".
+func FormatBytes(n float64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	value := n / unit
+	i := 0
+	for (value >= unit || value <= -unit) && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", value, suffixes[i])
+}
@@ -0,0 +1,55 @@
+package pbar
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"4096", 4096},
+		{"1,024", 1024},
+		{"512k", 512 << 10},
+		{"4G", 4 << 30},
+		{"1.5 MiB", 3 << 19},
+		{"12.3 MB", 12300000},
+		{"11 kB", 11000},
+		{"2TB", 2e12},
+	}
+	for _, tt := range tests {
+		actual, err := ParseSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseSize(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("ParseSize(%q): expected %d, got %d", tt.input, tt.expected, actual)
+		}
+	}
+
+	for _, input := range []string{"", "G", "12 parsecs", "--:--"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Errorf("ParseSize(%q): expected an error", input)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.00 KiB"},
+		{1536, "1.50 KiB"},
+		{12.34 * (1 << 20), "12.34 MiB"},
+		{4 << 30, "4.00 GiB"},
+		{3 << 40, "3.00 TiB"},
+	}
+	for _, tt := range tests {
+		if actual := FormatBytes(tt.input); actual != tt.expected {
+			t.Errorf("FormatBytes(%v): expected '%s', got '%s'", tt.input, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package pbar
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const (
+	stateFilePrefix = ".pbar."
+	stateFileSuffix = ".state"
+	lockFileSuffix  = ".lock"
+)
+
+func getStateFile(instanceID string) string {
+	return filepath.Join(os.TempDir(), stateFilePrefix+instanceID+stateFileSuffix)
+}
+
+func getLockFile(instanceID string) string {
+	return filepath.Join(os.TempDir(), stateFilePrefix+instanceID+lockFileSuffix)
+}
+
+// SaveState saves the bar state to a file.
+// The state is written to a temporary file which is then renamed over the
+// previous state, so concurrent readers never observe partially written JSON.
+func SaveState(b *Bar, instanceID string) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(getStateFile(instanceID), data, 0644)
+}
+
+// LoadState loads the bar state from a file.
+func LoadState(instanceID string) (*Bar, error) {
+	data, err := os.ReadFile(getStateFile(instanceID))
+	if err != nil {
+		return nil, err
+	}
+	var b Bar
+	err = json.Unmarshal(data, &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// DeleteState removes the state file.
+func DeleteState(instanceID string) error {
+	return os.Remove(getStateFile(instanceID))
+}
+
+// StateLock is an advisory lock guarding the state file of one bar instance.
+type StateLock struct {
+	f *os.File
+}
+
+// LockState acquires an exclusive advisory lock for the given instance,
+// blocking until it becomes available. Callers should hold the lock across
+// their whole LoadState/SaveState cycle and release it with Unlock.
+func LockState(instanceID string) (*StateLock, error) {
+	f, err := os.OpenFile(getLockFile(instanceID), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &StateLock{f: f}, nil
+}
+
+// Unlock releases the lock. The lock file itself is left in place so that
+// waiting processes keep contending on the same inode.
+func (l *StateLock) Unlock() error {
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package pbar
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// incrementState performs one locked load/modify/save cycle, the same way
+// the command-line tool does for each invocation.
+func incrementState(instanceID string) error {
+	lock, err := LockState(instanceID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	bar, err := LoadState(instanceID)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		bar = &Bar{Total: 1000}
+	}
+	bar.Current++
+	return SaveState(bar, instanceID)
+}
+
+func TestStateConcurrentUpdates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("advisory locking is not available on windows")
+	}
+
+	t.Run("goroutines never lose or tear updates", func(t *testing.T) {
+		t.Setenv("TMPDIR", t.TempDir())
+		instanceID := "hammer-goroutines"
+
+		const workers = 50
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := incrementState(instanceID); err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		bar, err := LoadState(instanceID)
+		if err != nil {
+			t.Fatalf("Failed to load final state: %v", err)
+		}
+		if bar.Current != workers {
+			t.Errorf("Expected current to be %d, got %d", workers, bar.Current)
+		}
+	})
+
+	t.Run("processes never lose or tear updates", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("TMPDIR", dir)
+		instanceID := "hammer-processes"
+
+		const processes = 8
+		const iterations = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, processes)
+		for i := 0; i < processes; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cmd := exec.Command(os.Args[0], "-test.run=TestStateHelperProcess")
+				cmd.Env = append(os.Environ(),
+					"PBAR_STATE_HELPER=1",
+					"PBAR_STATE_ID="+instanceID,
+					"PBAR_STATE_ITERATIONS="+strconv.Itoa(iterations),
+				)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					errs <- fmt.Errorf("%v: %s", err, out)
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatalf("Helper process failed: %v", err)
+		}
+
+		bar, err := LoadState(instanceID)
+		if err != nil {
+			t.Fatalf("Failed to load final state: %v", err)
+		}
+		if bar.Current != processes*iterations {
+			t.Errorf("Expected current to be %d, got %d", processes*iterations, bar.Current)
+		}
+	})
+}
+
+// TestStateHelperProcess is not a real test; it is re-executed as a child
+// process by TestStateConcurrentUpdates.
+func TestStateHelperProcess(t *testing.T) {
+	if os.Getenv("PBAR_STATE_HELPER") != "1" {
+		return
+	}
+	iterations, _ := strconv.Atoi(os.Getenv("PBAR_STATE_ITERATIONS"))
+	for i := 0; i < iterations; i++ {
+		if err := incrementState(os.Getenv("PBAR_STATE_ID")); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func TestSaveStateLeavesNoTemporaryFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("os.TempDir does not consult TMPDIR on windows")
+	}
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	if err := SaveState(&Bar{Total: 10, Current: 3}, "tidy"); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != ".pbar.tidy.state" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("Expected only the state file, got %v", names)
+	}
+}
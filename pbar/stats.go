@@ -0,0 +1,87 @@
+package pbar
+
+import "time"
+
+// Bar states reported by Stats.
+const (
+	StateRunning  = "running"
+	StatePaused   = "paused"
+	StateFinished = "finished"
+	StateFailed   = "failed"
+)
+
+// Stats is a read-only summary of a bar, for reporting progress to other
+// programs.
+type Stats struct {
+	ID      string   `json:"id,omitempty"` // Set by Manager
+	Current int64    `json:"current"`
+	Total   int64    `json:"total"`
+	Percent float64  `json:"percent"`
+	Unit    string   `json:"unit,omitempty"`
+	Rate    float64  `json:"rate"`    // Average throughput in units per second
+	ETA     *float64 `json:"eta"`     // Seconds left, or nil when unknown
+	Elapsed float64  `json:"elapsed"` // Seconds, excluding paused periods
+	State   string   `json:"state"`
+	Message string   `json:"message,omitempty"`
+	Error   string   `json:"error,omitempty"` // Failure reason of a failed bar
+}
+
+// Stats returns a summary of the bar as of its last render. Unlike Render,
+// it does not record a new throughput sample.
+func (b *Bar) Stats() Stats {
+	s := Stats{
+		Current: b.Current,
+		Total:   b.Total,
+		Unit:    b.Unit,
+		Rate:    b.averageThroughput(),
+		Elapsed: b.Elapsed().Seconds(),
+		State:   StateRunning,
+		Message: b.Message,
+	}
+	switch {
+	case b.Total > 0:
+		s.Percent = min(max(float64(b.Current)/float64(b.Total)*100, 0), 100)
+	case b.Current > 0:
+		s.Percent = 100 // As in Render, X/0 is 100%
+	}
+
+	switch {
+	case b.Failed:
+		s.State = StateFailed
+		s.Error = b.FailureMessage
+	case b.Finished:
+		s.State = StateFinished
+		s.Percent = 100
+	case b.Paused:
+		s.State = StatePaused
+	}
+
+	var eta time.Duration
+	switch {
+	case b.Failed:
+		return s
+	case !b.Deadline.IsZero():
+		eta = max(time.Until(b.Deadline), 0)
+	case b.Finished || (b.Total > 0 && b.Current >= b.Total):
+		eta = 0
+	case b.Total > 0 && s.Rate > 0:
+		eta = time.Duration(float64(b.Total-b.Current) / s.Rate * float64(time.Second))
+	default:
+		return s
+	}
+	seconds := eta.Seconds()
+	s.ETA = &seconds
+	return s
+}
+
+// averageThroughput returns the mean of the recent throughput samples.
+func (b *Bar) averageThroughput() float64 {
+	if len(b.ThroughputHistory) == 0 {
+		return 0
+	}
+	var total float64
+	for _, t := range b.ThroughputHistory {
+		total += t
+	}
+	return total / float64(len(b.ThroughputHistory))
+}
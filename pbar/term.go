@@ -0,0 +1,54 @@
+package pbar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fallbackColumns is the terminal width assumed by ParseWidth when the real
+// one is unknown.
+const fallbackColumns = 80
+
+// TerminalWidth returns the number of columns of the terminal f writes to,
+// falling back to $COLUMNS, or 0 if neither is known.
+func TerminalWidth(f *os.File) int {
+	if columns := terminalColumns(f); columns > 0 {
+		return columns
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 0
+}
+
+// ParseWidth parses a bar width given as a number of cells ("40"), a
+// percentage of a terminal of the given number of columns ("60%"), or
+// "auto" for a bar that fills the line (see Bar.AutoWidth), in which case
+// the returned width is 0.
+func ParseWidth(spec string, columns int) (width int, auto bool, err error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "auto" {
+		return 0, true, nil
+	}
+	if pct, ok := strings.CutSuffix(spec, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil || v <= 0 || v > 100 {
+			return 0, false, fmt.Errorf("invalid width '%s'. Percentages must be between 0 and 100", spec)
+		}
+		if columns <= 0 {
+			columns = fallbackColumns
+		}
+		return max(int(float64(columns)*v/100), 1), false, nil
+	}
+	width, err = strconv.Atoi(spec)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid width '%s'. Must be a number of cells, a percentage such as 60%%, or auto", spec)
+	}
+	if width <= 0 {
+		return 0, false, errors.New("width must be positive")
+	}
+	return width, false, nil
+}
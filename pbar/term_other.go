@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package pbar
+
+import "os"
+
+// terminalColumns is not implemented on this platform, leaving $COLUMNS as
+// the only source of the terminal width.
+func terminalColumns(f *os.File) int {
+	return 0
+}
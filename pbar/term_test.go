@@ -0,0 +1,53 @@
+package pbar
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseWidth(t *testing.T) {
+	tests := []struct {
+		spec     string
+		columns  int
+		width    int
+		auto     bool
+		hasError bool
+	}{
+		{"40", 120, 40, false, false},
+		{"auto", 120, 0, true, false},
+		{"60%", 120, 72, false, false},
+		{"50%", 0, 40, false, false}, // Assumes 80 columns
+		{"1%", 20, 1, false, false},
+		{"0", 120, 0, false, true},
+		{"-5", 120, 0, false, true},
+		{"150%", 120, 0, false, true},
+		{"wide", 120, 0, false, true},
+	}
+	for _, tt := range tests {
+		width, auto, err := ParseWidth(tt.spec, tt.columns)
+		if (err != nil) != tt.hasError {
+			t.Errorf("ParseWidth(%q): unexpected error state: %v", tt.spec, err)
+			continue
+		}
+		if width != tt.width || auto != tt.auto {
+			t.Errorf("ParseWidth(%q, %d): expected (%d, %t), got (%d, %t)", tt.spec, tt.columns, tt.width, tt.auto, width, auto)
+		}
+	}
+}
+
+func TestTerminalWidthFallback(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "not-a-terminal")
+	if err != nil {
+		t.Fatalf("Failed to create a file: %v", err)
+	}
+	defer f.Close()
+
+	t.Setenv("COLUMNS", "")
+	if width := TerminalWidth(f); width != 0 {
+		t.Errorf("Expected an unknown width for a regular file, got %d", width)
+	}
+	t.Setenv("COLUMNS", "132")
+	if width := TerminalWidth(f); width != 132 {
+		t.Errorf("Expected $COLUMNS to be used, got %d", width)
+	}
+}
@@ -0,0 +1,184 @@
+package pbar
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseTextUpdate parses one line of the plain-text update protocol, which
+// is easier to produce from a shell than JSON. Fields are separated by
+// spaces or tabs and the first one is the bar ID. The rest of the line is
+// either positional:
+//
+//	id current [total [message...]]
+//
+// where "-" leaves a value unchanged and "+N" adds N to the current value,
+// or a list of key=value pairs named like the JSON fields of Update:
+//
+//	id current=5 total=10 message="Extracting files" colorbar=green
+//
+// Values containing spaces can be double-quoted, with Go escaping rules.
+func ParseTextUpdate(line string) (Update, error) {
+	id, rest := cutField(line)
+	if id == "" {
+		return Update{}, errors.New("missing bar ID")
+	}
+	update := Update{ID: id}
+
+	var err error
+	if first, _ := cutField(rest); strings.Contains(first, "=") {
+		err = parseKeyValues(&update, rest)
+	} else {
+		err = parsePositional(&update, rest)
+	}
+	if err != nil {
+		return Update{}, err
+	}
+	return update, nil
+}
+
+func parsePositional(update *Update, line string) error {
+	current, rest := cutField(line)
+	total, rest := cutField(rest)
+	message := strings.TrimSpace(rest)
+
+	switch {
+	case current == "" || current == "-":
+	case strings.HasPrefix(current, "+"):
+		delta, err := strconv.ParseInt(current[1:], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid delta '%s'", current)
+		}
+		update.Delta = delta
+	default:
+		v, err := strconv.ParseInt(current, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid current value '%s'", current)
+		}
+		update.Current = &v
+	}
+	if total != "" && total != "-" {
+		v, err := strconv.ParseInt(total, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid total '%s'", total)
+		}
+		update.Total = &v
+	}
+	if message != "" {
+		update.Message = &message
+	}
+	return nil
+}
+
+func parseKeyValues(update *Update, line string) error {
+	rest := line
+	for {
+		rest = strings.TrimLeft(rest, " \t\r")
+		if rest == "" {
+			return nil
+		}
+		eq := strings.IndexByte(rest, '=')
+		if sp := strings.IndexAny(rest, " \t"); eq < 0 || (sp >= 0 && sp < eq) {
+			field, _ := cutField(rest)
+			return fmt.Errorf("expected key=value, got '%s'", field)
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return fmt.Errorf("invalid quoted value for '%s'", key)
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			value, rest = cutField(rest)
+		}
+		if err := setUpdateField(update, key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// setUpdateField sets the field of update whose JSON name is key.
+func setUpdateField(update *Update, key, value string) error {
+	parseInt := func() (int64, error) {
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s '%s'", key, value)
+		}
+		return v, nil
+	}
+	parseBool := func() (*bool, error) {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s'", key, value)
+		}
+		return &v, nil
+	}
+
+	var err error
+	switch key {
+	case "op":
+		update.Op = value
+	case "current":
+		var v int64
+		if v, err = parseInt(); err == nil {
+			update.Current = &v
+		}
+	case "delta":
+		update.Delta, err = parseInt()
+	case "total":
+		var v int64
+		if v, err = parseInt(); err == nil {
+			update.Total = &v
+		}
+	case "width":
+		var v int64
+		if v, err = parseInt(); err == nil {
+			if v <= 0 {
+				return fmt.Errorf("invalid %s '%s'. Must be positive", key, value)
+			}
+			update.Width = int(v)
+		}
+	case "style":
+		update.Style = value
+	case "unit":
+		update.Unit = value
+	case "colorbar":
+		update.ColorBar = value
+	case "colortext":
+		update.ColorText = value
+	case "chars":
+		update.CustomChars = value
+	case "format":
+		update.Format = value
+	case "message":
+		update.Message = &value
+	case "finished":
+		update.Finished, err = parseBool()
+	case "showelapsed":
+		update.ShowElapsed, err = parseBool()
+	case "showthroughput":
+		update.ShowThroughput, err = parseBool()
+	case "showeta":
+		update.ShowETA, err = parseBool()
+	default:
+		return fmt.Errorf("unknown key '%s'", key)
+	}
+	return err
+}
+
+// cutField returns the first space- or tab-separated field of s and the
+// remainder of s after it.
+func cutField(s string) (field, rest string) {
+	s = strings.TrimLeft(s, " \t\r")
+	if i := strings.IndexAny(s, " \t\r"); i >= 0 {
+		return s[:i], s[i:]
+	}
+	return s, ""
+}
@@ -0,0 +1,62 @@
+package pbar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestParseTextUpdate(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Update
+	}{
+		{"job1 10 100", Update{ID: "job1", Current: int64Ptr(10), Total: int64Ptr(100)}},
+		{"job1\t10\t100\tDownloading file1.zip", Update{ID: "job1", Current: int64Ptr(10), Total: int64Ptr(100), Message: stringPtr("Downloading file1.zip")}},
+		{"job1 10 100   spaced   message  ", Update{ID: "job1", Current: int64Ptr(10), Total: int64Ptr(100), Message: stringPtr("spaced   message")}},
+		{"job1 +3", Update{ID: "job1", Delta: 3}},
+		{"job1 - - Extracting", Update{ID: "job1", Message: stringPtr("Extracting")}},
+		{"job1", Update{ID: "job1"}},
+		{"job1 current=5 total=10 style=block colorbar=green", Update{ID: "job1", Current: int64Ptr(5), Total: int64Ptr(10), Style: "block", ColorBar: "green"}},
+		{`job1 message="Extracting \"big\" files" delta=2`, Update{ID: "job1", Message: stringPtr(`Extracting "big" files`), Delta: 2}},
+		{"job1 op=fail message=timeout", Update{ID: "job1", Op: OpFail, Message: stringPtr("timeout")}},
+		{"job1 finished=true showeta=false width=20 chars=#.", Update{ID: "job1", Finished: boolPtr(true), ShowETA: boolPtr(false), Width: 20, CustomChars: "#."}},
+		{"job1 message=", Update{ID: "job1", Message: stringPtr("")}},
+		{`job1 format="{bar} {percent:.1f}"`, Update{ID: "job1", Format: "{bar} {percent:.1f}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			actual, err := ParseTextUpdate(tt.line)
+			if err != nil {
+				t.Fatalf("ParseTextUpdate(%q) failed: %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseTextUpdateErrors(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"   ",
+		"job1 ten 100",
+		"job1 10 many",
+		"job1 +x",
+		"job1 current=5 stray",
+		"job1 colour=red",
+		"job1 current=five",
+		"job1 width=0",
+		"job1 width=-3",
+		"job1 finished=maybe",
+		`job1 message="unterminated`,
+	} {
+		if _, err := ParseTextUpdate(line); err == nil {
+			t.Errorf("Expected an error for %q", line)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package pbar
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Ticker redraws a single Bar on a fixed interval, so spinners keep moving
+// and elapsed time keeps counting even when no new progress arrives.
+// All access to the bar must go through Update while the ticker is running.
+type Ticker struct {
+	bar      *Bar
+	out      io.Writer
+	interval time.Duration
+	mu       sync.Mutex
+	last     string // Last rendered line, reused when printing above the bar
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewTicker creates a Ticker that renders bar to out every interval.
+func NewTicker(bar *Bar, out io.Writer, interval time.Duration) *Ticker {
+	return &Ticker{
+		bar:      bar,
+		out:      out,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start draws the bar immediately and then on every tick until Stop is called.
+func (t *Ticker) Start() {
+	t.render()
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.render()
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Update calls fn with exclusive access to the bar.
+func (t *Ticker) Update(fn func(b *Bar)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(t.bar)
+}
+
+// Println clears the bar, writes line to w and redraws the last frame below
+// it. w is usually the same terminal as the ticker's output.
+func (t *Ticker) Println(w io.Writer, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprint(t.out, "\r\x1b[K")
+	fmt.Fprintln(w, line)
+	fmt.Fprint(t.out, t.last)
+}
+
+// Stop halts the ticker and draws the final state of the bar followed by a
+// newline, leaving it on screen.
+func (t *Ticker) Stop() {
+	close(t.stop)
+	<-t.done
+	t.render()
+	fmt.Fprintln(t.out)
+}
+
+func (t *Ticker) render() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = t.bar.Render()
+	fmt.Fprint(t.out, t.last)
+}
@@ -0,0 +1,75 @@
+package pbar
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for use from the ticker goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func TestTicker(t *testing.T) {
+	t.Run("animates a spinner without updates", func(t *testing.T) {
+		var out syncBuffer
+		ticker := NewTicker(&Bar{Style: "spinner"}, &out, 5*time.Millisecond)
+		ticker.Start()
+		time.Sleep(50 * time.Millisecond)
+		ticker.Stop()
+
+		output := out.String()
+		for _, frame := range []string{"[|]", "[/]", "[-]"} {
+			if !strings.Contains(output, frame) {
+				t.Errorf("Expected output to contain frame %s, got '%s'", frame, output)
+			}
+		}
+		if !strings.HasSuffix(output, "\n") {
+			t.Errorf("Expected output to end with a newline")
+		}
+	})
+
+	t.Run("draws the final state on stop", func(t *testing.T) {
+		var out syncBuffer
+		ticker := NewTicker(&Bar{Total: 10, Width: 10}, &out, time.Hour)
+		ticker.Start()
+		ticker.Update(func(b *Bar) {
+			b.Current = 10
+			b.Finished = true
+		})
+		ticker.Stop()
+
+		if !strings.HasSuffix(out.String(), "\r[✔] 100% Task Complete!\x1b[K\n") {
+			t.Errorf("Expected the finished bar at the end, got '%s'", out.String())
+		}
+	})
+
+	t.Run("prints lines above the bar", func(t *testing.T) {
+		var out syncBuffer
+		ticker := NewTicker(&Bar{Total: 10, Current: 5, Width: 10}, &out, time.Hour)
+		ticker.Start()
+		ticker.Println(&out, "hello")
+		ticker.Stop()
+
+		expected := "\r[#####-----] 50%\x1b[K\r\x1b[Khello\n\r[#####-----] 50%\x1b[K"
+		if !strings.HasPrefix(out.String(), expected) {
+			t.Errorf("Expected output to start with '%s', got '%s'", expected, out.String())
+		}
+	})
+}
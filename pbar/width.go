@@ -0,0 +1,188 @@
+package pbar
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	emojiPresentation = '\ufe0f' // Variation selector asking for the emoji (wide) form of the previous character
+)
+
+// wideRanges lists the characters a terminal draws two columns wide: the
+// East Asian Wide and Fullwidth characters and the emoji shown as pictures
+// by default. It must stay sorted.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115f}, // Hangul Jamo initial consonants
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},   // CJK radicals, Kangxi, CJK symbols and punctuation
+	{0x3041, 0x33ff},   // Hiragana, Katakana, Bopomofo, CJK compatibility
+	{0x3400, 0x4dbf},   // CJK Extension A
+	{0x4e00, 0x9fff},   // CJK Unified Ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xa960, 0xa97f},   // Hangul Jamo Extended-A
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK Compatibility Ideographs
+	{0xfe10, 0xfe19},   // Vertical forms
+	{0xfe30, 0xfe6f},   // CJK compatibility forms, small form variants
+	{0xff00, 0xff60},   // Fullwidth forms
+	{0xffe0, 0xffe6},   // Fullwidth signs
+	{0x16fe0, 0x16fe4}, // Ideographic symbols
+	{0x17000, 0x18cff}, // Tangut
+	{0x1b000, 0x1b2ff}, // Kana supplements, Nushu
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f2ff}, // Enclosed ideographic supplement
+	{0x1f300, 0x1f64f}, // Pictographs and emoticons
+	{0x1f680, 0x1f6ff}, // Transport and map symbols
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff}, // Supplemental symbols and pictographs
+	{0x1fa70, 0x1faff}, // Symbols and pictographs extended-A
+	{0x20000, 0x2fffd}, // CJK Extensions B to F
+	{0x30000, 0x3fffd}, // CJK Extension G and later
+}
+
+// displayWidth returns the number of terminal columns s occupies, ignoring
+// ANSI escape sequences.
+func displayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		size, w := nextGrapheme(s[i:])
+		width += w
+		i += size
+	}
+	return width
+}
+
+// graphemes splits s into user-perceived characters, such as a letter and
+// its accents or an emoji sequence.
+func graphemes(s string) []string {
+	var clusters []string
+	for len(s) > 0 {
+		size, _ := nextGrapheme(s)
+		clusters = append(clusters, s[:size])
+		s = s[size:]
+	}
+	return clusters
+}
+
+// nextGrapheme returns the length in bytes and the width in columns of the
+// character at the start of s, together with the combining marks,
+// variation selectors and joined emoji that follow it. This covers what
+// terminals draw as one character without implementing every rule of
+// Unicode text segmentation.
+func nextGrapheme(s string) (size, width int) {
+	r, size := utf8.DecodeRuneInString(s)
+	width = runeWidth(r)
+	pairable := isRegionalIndicator(r)
+	for size < len(s) {
+		next, n := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case next == zeroWidthJoiner:
+			// The joined character is drawn as part of this one
+			size += n
+			if size < len(s) {
+				_, n = utf8.DecodeRuneInString(s[size:])
+				size += n
+			}
+		case next == emojiPresentation:
+			width = max(width, 2)
+			size += n
+		case isExtender(next):
+			size += n
+		case pairable && isRegionalIndicator(next):
+			// Two regional indicators make a flag
+			width = 2
+			pairable = false
+			size += n
+		default:
+			return size, width
+		}
+	}
+	return size, width
+}
+
+// runeWidth returns the number of columns r occupies on its own.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0 // Control characters such as "\r"
+	case r < 0x300:
+		return 1
+	case isExtender(r) || r == zeroWidthJoiner:
+		return 0
+	}
+	i := sort.Search(len(wideRanges), func(i int) bool { return wideRanges[i].hi >= r })
+	if i < len(wideRanges) && wideRanges[i].lo <= r {
+		return 2
+	}
+	return 1
+}
+
+// isExtender reports whether r is drawn as part of the character before it:
+// combining marks, variation selectors, format characters, Hangul vowels and
+// finals, and emoji skin tone modifiers.
+func isExtender(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) ||
+		(r >= 0x1160 && r <= 0x11ff) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// escapeLen returns the length of the CSI escape sequence (such as a color
+// or "\x1b[K") at the start of s, or 0 if there is none.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,70 @@
+package pbar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected int
+	}{
+		{"ascii", "hello", 5},
+		{"empty", "", 0},
+		{"control", "\r\x1b[K", 0},
+		{"colored", "\x1b[32mok\x1b[0m", 2},
+		{"latin accents", "déjà vu", 7},
+		{"combining acute", "cafe\u0301", 4},
+		{"combining marks", "a\u0323\u0308", 1},
+		{"cjk", "下载", 4},
+		{"hiragana", "ひらがな", 8},
+		{"hangul", "한국어", 6},
+		{"hangul jamo", "\u1100\u1161\u11a8", 2},
+		{"fullwidth", "ＡＢＣ", 6},
+		{"halfwidth katakana", "ｶﾀｶﾅ", 4},
+		{"box drawing", "█░▓", 3},
+		{"braille", "⣿⡀", 2},
+		{"status icons", "✔✘", 2},
+		{"emoji", "🚀", 2},
+		{"emoji and text", "🚀 go", 5},
+		{"text symbol", "❤", 1},
+		{"emoji presentation", "❤\ufe0f", 2},
+		{"text presentation", "\u263a\ufe0e", 1},
+		{"skin tone", "👍🏽", 2},
+		{"zwj sequence", "👨\u200d👩\u200d👧", 2},
+		{"flag", "🇫🇷", 2},
+		{"two flags", "🇫🇷🇩🇪", 4},
+		{"keycap", "1\ufe0f\u20e3", 2},
+		{"zero width space", "a\u200bb", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := displayWidth(tt.s); actual != tt.expected {
+				t.Errorf("displayWidth(%q): expected %d, got %d", tt.s, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected []string
+	}{
+		{"", nil},
+		{"#=", []string{"#", "="}},
+		{"█░", []string{"█", "░"}},
+		{"e\u0301a", []string{"e\u0301", "a"}},
+		{"🟩⬜", []string{"🟩", "⬜"}},
+		{"👨\u200d👩\u200d👧👍🏽", []string{"👨\u200d👩\u200d👧", "👍🏽"}},
+		{"🇫🇷🇩🇪", []string{"🇫🇷", "🇩🇪"}},
+		{"❤\ufe0f-", []string{"❤\ufe0f", "-"}},
+	}
+	for _, tt := range tests {
+		if actual := graphemes(tt.s); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("graphemes(%q): expected %q, got %q", tt.s, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// runPid draws how far process pid has read through descriptor fd, or
+// through its largest open file when fd is negative, until the process exits
+// or closes the file.
+func runPid(bar *pbar.Bar, pid, fd int) int {
+	if fd < 0 {
+		var err error
+		fd, err = pbar.LargestOpenFile(pid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not find a file to track in process %d: %v\n", pid, err)
+			return 1
+		}
+	}
+	position, err := pbar.ReadFilePosition(pid, fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not read descriptor %d of process %d: %v\n", fd, pid, err)
+		return 1
+	}
+
+	bar.Total = position.Size
+	bar.Current = position.Pos
+	bar.PreviousCurrent = position.Pos // Progress made before we attached is not throughput
+	if bar.Message == "" {
+		bar.Message = filepath.Base(position.Path)
+	}
+
+	ticker := startStreamTicker(bar, defaultTickInterval)
+	err = followPosition(pid, fd, position, defaultTickInterval, pidStallTime, func(position pbar.FilePosition) {
+		ticker.Update(func(b *pbar.Bar) {
+			b.Total = position.Size
+			b.Current = position.Pos
+		})
+	})
+	return stopStreamTicker(ticker, err)
+}
+
+// pidStallTime is how long the position must have stood still before the
+// process exited for --pid to report that it stopped short of the end.
+const pidStallTime = 2 * time.Second
+
+// followPosition reports the position of descriptor fd in process pid, last
+// read as position, every interval until the process exits or closes the
+// file. That counts as success, since a fast reader can get through the rest
+// of the file and exit between two reads. Only a process whose position had
+// stood still for stall short of the end, such as one that hung and was then
+// killed, returns an error.
+func followPosition(pid, fd int, position pbar.FilePosition, interval, stall time.Duration, update func(pbar.FilePosition)) error {
+	moved := time.Now()
+	for {
+		time.Sleep(interval)
+		if !pbar.ProcessExists(pid) {
+			break
+		}
+		next, err := pbar.ReadFilePosition(pid, fd)
+		if err != nil {
+			break // The descriptor was closed, so the process is done with the file
+		}
+		if next.Pos != position.Pos {
+			moved = time.Now()
+		}
+		position = next
+		update(position)
+	}
+	if position.Pos < position.Size && time.Since(moved) >= stall {
+		return fmt.Errorf("process %d stopped at %s of %s", pid,
+			pbar.FormatBytes(float64(position.Pos)), pbar.FormatBytes(float64(position.Size)))
+	}
+	return nil
+}
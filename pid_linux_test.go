@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// startReader starts helper with stdin open on a file of size bytes at
+// offset. The helper shares the open file, and so its offset, as its stdin.
+func startReader(t *testing.T, helper *exec.Cmd, size, offset int64) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.bin")
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open input: %v", err)
+	}
+	defer f.Close() // The helper keeps its own copy
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	helper.Stdin = f
+	if err := helper.Start(); err != nil {
+		t.Fatalf("Failed to start helper: %v", err)
+	}
+}
+
+func TestFollowPosition(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int64
+		fails  bool
+	}{
+		{"killed after standing still partway through the file", 1000, true},
+		{"killed at the end of the file", 4096, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper := exec.Command("sleep", "10")
+			startReader(t, helper, 4096, tt.offset)
+			pid := helper.Process.Pid
+			position, err := pbar.ReadFilePosition(pid, 0)
+			if err != nil {
+				helper.Process.Kill()
+				helper.Wait()
+				t.Fatalf("ReadFilePosition failed: %v", err)
+			}
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				helper.Process.Kill()
+				helper.Wait()
+			}()
+
+			updates := 0
+			err = followPosition(pid, 0, position, 10*time.Millisecond, 50*time.Millisecond, func(p pbar.FilePosition) {
+				updates++
+				if p.Pos != tt.offset || p.Size != 4096 {
+					t.Errorf("Unexpected position %+v", p)
+				}
+			})
+			if updates == 0 {
+				t.Errorf("Expected positions to be reported while the helper ran")
+			}
+			if (err != nil) != tt.fails {
+				t.Errorf("Expected failure=%v, got %v", tt.fails, err)
+			}
+		})
+	}
+
+	t.Run("reader that finishes between two reads", func(t *testing.T) {
+		helper := exec.Command(os.Args[0], "-test.run=^TestPositionHelper$")
+		helper.Env = append(os.Environ(), "PBAR_POSITION_HELPER=1")
+		startReader(t, helper, 16<<10, 0)
+		defer helper.Wait()
+		pid := helper.Process.Pid
+		position, err := pbar.ReadFilePosition(pid, 0)
+		if err != nil {
+			helper.Process.Kill()
+			t.Fatalf("ReadFilePosition failed: %v", err)
+		}
+
+		var last pbar.FilePosition
+		moves := 0
+		err = followPosition(pid, 0, position, 30*time.Millisecond, 200*time.Millisecond, func(p pbar.FilePosition) {
+			if p.Pos > last.Pos {
+				moves++
+			}
+			last = p
+		})
+		if err != nil {
+			t.Errorf("Expected success, got %v", err)
+		}
+		if moves < 2 {
+			t.Errorf("Expected the position to move between reads, saw it move %d times", moves)
+		}
+		if last.Pos >= last.Size {
+			t.Errorf("Expected the last read to be short of the end, got %+v", last)
+		}
+	})
+}
+
+// TestPositionHelper is not a real test but the helper process of
+// TestFollowPosition. It reads the first half of its stdin slowly, then
+// the rest at once, and exits straight away.
+func TestPositionHelper(t *testing.T) {
+	if os.Getenv("PBAR_POSITION_HELPER") == "" {
+		return
+	}
+	buf := make([]byte, 256)
+	for read := 0; read < 8<<10; read += len(buf) {
+		if _, err := io.ReadFull(os.Stdin, buf); err != nil {
+			os.Exit(1)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	io.Copy(io.Discard, os.Stdin)
+	syscall.Exit(0) // Unlike os.Exit, without the race detector's delay at exit
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+const pipeBufferSize = 64 * 1024
+
+// runPipe copies stdin to stdout unchanged while drawing bar on stderr from
+// the number of bytes copied, and returns the exit code.
+func runPipe(bar *pbar.Bar) int {
+	ticker := startStreamTicker(bar, defaultTickInterval)
+	err := copyCounting(os.Stdout, os.Stdin, func(p []byte) {
+		ticker.Update(func(b *pbar.Bar) {
+			b.Current += int64(len(p))
+		})
+	})
+	return stopStreamTicker(ticker, err)
+}
+
+// startStreamTicker starts drawing bar on stderr for the modes that run on
+// their own, keeping stdout free for any data flowing through.
+func startStreamTicker(bar *pbar.Bar, interval time.Duration) *pbar.Ticker {
+	ticker := pbar.NewTicker(bar, os.Stderr, interval)
+
+	// Handle Ctrl+C to show cursor before exiting
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		fmt.Fprint(os.Stderr, "\n\033[?25h") // Show cursor
+		os.Exit(130)
+	}()
+
+	fmt.Fprint(os.Stderr, "\033[?25l") // Hide cursor
+	ticker.Start()
+	return ticker
+}
+
+// stopStreamTicker draws the final state of the bar and returns the exit
+// code for err.
+func stopStreamTicker(ticker *pbar.Ticker, err error) int {
+	ticker.Update(func(b *pbar.Bar) {
+		if err != nil {
+			b.Fail(err.Error())
+		} else {
+			b.Finished = true
+		}
+	})
+	ticker.Stop()
+	fmt.Fprint(os.Stderr, "\033[?25h") // Show cursor
+
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// copyCounting copies src to dst and reports every chunk written.
+func copyCounting(dst io.Writer, src io.Reader, counted func(p []byte)) error {
+	buf := make([]byte, pipeBufferSize)
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			written, err := dst.Write(buf[:n])
+			counted(buf[:written])
+			if err != nil {
+				return fmt.Errorf("write: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("read: %w", readErr)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+const defaultTickInterval = 100 * time.Millisecond
+
+// runSpin runs a child command while animating a spinner on stderr, and
+// returns the child's exit code.
+func runSpin(args []string) int {
+	fs := flag.NewFlagSet("spin", flag.ExitOnError)
+	fs.SetInterspersed(false)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pbar spin [flags] -- <command> [args...]\n")
+		fs.PrintDefaults()
+	}
+
+	style := fs.String("style", "spinner", "Spinner style (spinner, braille-spinner)")
+	message := fs.String("message", "", "Message to display next to the spinner")
+	colorTextName := fs.String("colortext", "", fmt.Sprintf("Color for the spinner. Available: %s", pbar.GetAvailableColors()))
+	finishedMessage := fs.String("finished-message", "", "Message to display when the command succeeds")
+	showOutput := fs.Bool("show-output", false, "Print the command's captured output even when it succeeds")
+	interval := fs.Duration("interval", defaultTickInterval, "Time between spinner frames")
+	fs.Parse(args)
+
+	cmdArgs := fs.Args()
+	if len(cmdArgs) == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *style != "spinner" && *style != "braille-spinner" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid spinner style '%s'. Must be one of: spinner, braille-spinner\n", *style)
+		return 2
+	}
+
+	bar := &pbar.Bar{
+		Style:             *style,
+		ColorText:         pbar.GetColorCode(*colorTextName),
+		Message:           *message,
+		CompletionMessage: *finishedMessage,
+		StartTime:         time.Now(),
+		ShowElapsed:       true,
+	}
+
+	// The child's output is captured so it does not tear through the spinner.
+	// Stdout and Stderr share one buffer, which exec serialises for us.
+	var output bytes.Buffer
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	ticker := pbar.NewTicker(bar, os.Stderr, *interval)
+	fmt.Fprint(os.Stderr, "\033[?25l") // Hide cursor
+	ticker.Start()
+
+	err := runChild(cmd, nil)
+	exitCode := exitCodeOf(err)
+
+	ticker.Update(func(b *pbar.Bar) {
+		if exitCode == 0 {
+			b.Finished = true
+		} else {
+			b.Fail(describeFailure(cmdArgs[0], err, exitCode))
+		}
+	})
+	ticker.Stop()
+	fmt.Fprint(os.Stderr, "\033[?25h") // Show cursor
+
+	if exitCode != 0 {
+		os.Stderr.Write(output.Bytes())
+	} else if *showOutput {
+		os.Stdout.Write(output.Bytes())
+	}
+	return exitCode
+}
+
+// runChild starts cmd and waits for it. If consume is not nil it is called
+// after the child has started and must drain any pipes before returning.
+// While the child runs, interrupts are left to it (the terminal delivers them
+// to the whole process group) and termination requests are forwarded to it,
+// so pbar always outlives the child and can report how it ended.
+func runChild(cmd *exec.Cmd, consume func()) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	waitDone := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGTERM {
+					cmd.Process.Signal(sig)
+				}
+			case <-waitDone:
+				return
+			}
+		}
+	}()
+
+	if consume != nil {
+		consume()
+	}
+	err := cmd.Wait()
+	close(waitDone)
+	return err
+}
+
+// exitCodeOf converts the result of running a child into a shell-style exit
+// code: 128+N for a child killed by signal N, and 127 when it could not be
+// started at all.
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+	return 127
+}
+
+func describeFailure(name string, err error, exitCode int) string {
+	name = filepath.Base(name)
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Sprintf("%s: %v", name, err)
+	}
+	return fmt.Sprintf("%s exited with code %d", name, exitCode)
+}
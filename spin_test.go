@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExitCodeOf(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("No shell to run the children")
+	}
+	tests := []struct {
+		name     string
+		command  []string
+		exitCode int
+		failure  string // Start of the failure text
+	}{
+		{"success", []string{"sh", "-c", "exit 0"}, 0, ""},
+		{"exit code", []string{"sh", "-c", "exit 3"}, 3, "sh exited with code 3"},
+		{"killed by a signal", []string{"sh", "-c", "kill -TERM $$"}, 128 + 15, "sh exited with code 143"},
+		{"killed by SIGKILL", []string{"sh", "-c", "kill -KILL $$"}, 128 + 9, "sh exited with code 137"},
+		{"not found", []string{"/no/such/dir/pbar-missing-command"}, 127, "pbar-missing-command: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runChild(exec.Command(tt.command[0], tt.command[1:]...), nil)
+			exitCode := exitCodeOf(err)
+			if exitCode != tt.exitCode {
+				t.Errorf("Expected exit code %d, got %d (%v)", tt.exitCode, exitCode, err)
+			}
+			if tt.failure == "" {
+				return
+			}
+			if failure := describeFailure(tt.command[0], err, exitCode); !strings.HasPrefix(failure, tt.failure) {
+				t.Errorf("Expected failure text starting with %q, got %q", tt.failure, failure)
+			}
+		})
+	}
+}
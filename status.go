@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// serveStatus serves the HTTP status endpoints of manager on addr in the
+// background. Listening errors are returned right away.
+func serveStatus(addr string, manager *pbar.Manager) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go http.Serve(l, pbar.NewHTTPHandler(manager))
+	return nil
+}
+
+// metricsFile rewrites a metrics file after each render. Only the first
+// failure is reported, so a bad path does not flood the terminal.
+type metricsFile struct {
+	path     string
+	reported bool
+}
+
+func (f *metricsFile) write(manager *pbar.Manager) {
+	if f == nil {
+		return
+	}
+	if err := manager.WriteMetricsFile(f.path); err != nil && !f.reported {
+		fmt.Fprintf(os.Stderr, "Error writing metrics to %s: %v\n", f.path, err)
+		f.reported = true
+	}
+}
+
+// newMetricsFile returns a metricsFile for path, or nil if path is empty.
+func newMetricsFile(path string) *metricsFile {
+	if path == "" {
+		return nil
+	}
+	return &metricsFile{path: path}
+}
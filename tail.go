@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// runTail follows a log file and advances a bar for every line matching a
+// step pattern, until a done or fail pattern shows up.
+func runTail(args []string) int {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pbar tail <file> --step <regex> [--total <n>] [--done <regex>] [--fail <regex>] [flags]\n")
+		fs.PrintDefaults()
+	}
+
+	bf := addBarFlags(fs)
+	bf.addFormatFlag(fs)
+	stepExpr := fs.String("step", "", "Advance the bar for each line matching this regular expression")
+	doneExpr := fs.String("done", "", "Finish the bar when a line matches this regular expression")
+	failExpr := fs.String("fail", "", "Fail the bar, showing the matching line, when a line matches this regular expression")
+	total := fs.Int64("total", 0, "Number of steps expected (0 shows a spinner with the count)")
+	fromStart := fs.Bool("from-start", false, "Read the lines already in the file instead of starting at its end")
+	poll := fs.Duration("poll", 250*time.Millisecond, "Time between checks for new lines")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *stepExpr == "" {
+		fmt.Fprintf(os.Stderr, "Error: --step is required\n")
+		return 2
+	}
+	stepRe, err := regexp.Compile(*stepExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --step expression: %v\n", err)
+		return 2
+	}
+	doneRe, err := compileOptional(*doneExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --done expression: %v\n", err)
+		return 2
+	}
+	failRe, err := compileOptional(*failExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --fail expression: %v\n", err)
+		return 2
+	}
+	if *total <= 0 && bf.style != "spinner" && bf.style != "braille-spinner" {
+		bf.style = "spinner" // Without a total there is no percentage to show
+	}
+	bar, err := bf.newBar(*total)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	lf := &logFollower{path: fs.Arg(0), skipExisting: !*fromStart}
+	defer lf.close()
+
+	ticker := startStreamTicker(bar, bf.interval)
+	m := &tailMatcher{step: stepRe, done: doneRe, fail: failRe, total: *total}
+	handle := func(line string) bool {
+		steps := m.steps
+		ended := m.match(line)
+		if m.steps != steps {
+			steps = m.steps
+			ticker.Update(func(b *pbar.Bar) {
+				b.Current = steps
+			})
+		}
+		return ended
+	}
+
+	var result error
+	for !m.finished && m.err == nil {
+		if err := lf.poll(handle); err != nil {
+			result = err
+			break
+		}
+		if !m.finished && m.err == nil {
+			time.Sleep(*poll)
+		}
+	}
+	if result == nil {
+		result = m.err
+	}
+	return stopStreamTicker(ticker, result)
+}
+
+// tailMatcher decides what each line of the log means for the bar.
+type tailMatcher struct {
+	step, done, fail *regexp.Regexp // done and fail are optional
+	total            int64
+	steps            int64
+	finished         bool
+	err              error // The line that matched fail
+}
+
+// match handles a line and reports whether the bar has ended, either
+// finished or failed.
+func (m *tailMatcher) match(line string) bool {
+	switch {
+	case m.fail != nil && m.fail.MatchString(line):
+		m.err = errors.New(strings.TrimSpace(line))
+		return true
+	case m.done != nil && m.done.MatchString(line):
+		m.finished = true
+		return true
+	case m.step.MatchString(line):
+		m.steps++
+		// Without a done pattern, reaching the total is the only way to end
+		m.finished = m.done == nil && m.total > 0 && m.steps >= m.total
+		return m.finished
+	}
+	return false
+}
+
+func compileOptional(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
+
+// logFollower reads the lines appended to a file, following it across
+// rotation (the path being replaced by a new file) and truncation, like
+// "tail -F".
+type logFollower struct {
+	path         string
+	skipExisting bool // Start at the end of the file if it already exists
+	f            *os.File
+	offset       int64
+	partial      []byte // Start of a line whose newline has not been written yet
+	buf          []byte
+}
+
+// poll passes every complete line written since the last call to handle,
+// stopping early if handle returns true.
+func (lf *logFollower) poll(handle func(line string) bool) error {
+	if lf.f == nil {
+		f, err := os.Open(lf.path)
+		if os.IsNotExist(err) {
+			lf.skipExisting = false // Everything in a file created later is new
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		lf.f, lf.offset = f, 0
+		if lf.skipExisting {
+			if lf.offset, err = f.Seek(0, io.SeekEnd); err != nil {
+				return err
+			}
+			lf.skipExisting = false
+		}
+	}
+
+	if stop, err := lf.drain(handle); stop || err != nil {
+		return err
+	}
+
+	info, err := os.Stat(lf.path)
+	if os.IsNotExist(err) {
+		return nil // Rotated away and not recreated yet
+	}
+	if err != nil {
+		return err
+	}
+	current, err := lf.f.Stat()
+	if err != nil {
+		return err
+	}
+	switch {
+	case !os.SameFile(info, current):
+		// The old file was fully drained above; a line left without its
+		// newline will never be completed.
+		if len(lf.partial) > 0 {
+			line := string(lf.partial)
+			lf.partial = nil
+			if handle(line) {
+				return nil
+			}
+		}
+		lf.close()
+		return lf.poll(handle)
+	case info.Size() < lf.offset:
+		if _, err := lf.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		lf.offset, lf.partial = 0, nil
+		_, err := lf.drain(handle)
+		return err
+	}
+	return nil
+}
+
+// drain reads the open file to its current end.
+func (lf *logFollower) drain(handle func(line string) bool) (bool, error) {
+	if lf.buf == nil {
+		lf.buf = make([]byte, pipeBufferSize)
+	}
+	for {
+		n, err := lf.f.Read(lf.buf)
+		lf.offset += int64(n)
+		data := lf.buf[:n]
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			line := append(lf.partial, data[:i]...)
+			lf.partial = lf.partial[:0]
+			data = data[i+1:]
+			if handle(string(bytes.TrimSuffix(line, []byte("\r")))) {
+				return true, nil
+			}
+		}
+		lf.partial = append(lf.partial, data...)
+		if err == io.EOF || n == 0 {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+func (lf *logFollower) close() {
+	if lf.f != nil {
+		lf.f.Close()
+		lf.f = nil
+	}
+}
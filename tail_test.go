@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// pollLines returns the lines lf passes on in one poll.
+func pollLines(t *testing.T, lf *logFollower) []string {
+	t.Helper()
+	var lines []string
+	err := lf.poll(func(line string) bool {
+		lines = append(lines, line)
+		return false
+	})
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	return lines
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func expectLines(t *testing.T, got []string, expected ...string) {
+	t.Helper()
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected lines %q, got %q", expected, got)
+	}
+}
+
+func TestLogFollower(t *testing.T) {
+	t.Run("lines appended across polls", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		appendFile(t, path, "already there\n")
+		lf := &logFollower{path: path, skipExisting: true}
+		defer lf.close()
+
+		expectLines(t, pollLines(t, lf))
+		appendFile(t, path, "one\r\ntwo\n")
+		expectLines(t, pollLines(t, lf), "one", "two")
+		appendFile(t, path, "three\n")
+		expectLines(t, pollLines(t, lf), "three")
+		expectLines(t, pollLines(t, lf))
+	})
+
+	t.Run("existing lines read from the start", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		appendFile(t, path, "already there\n")
+		lf := &logFollower{path: path}
+		defer lf.close()
+
+		expectLines(t, pollLines(t, lf), "already there")
+	})
+
+	t.Run("file created after the first poll", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		lf := &logFollower{path: path, skipExisting: true}
+		defer lf.close()
+
+		expectLines(t, pollLines(t, lf))
+		appendFile(t, path, "first\n")
+		expectLines(t, pollLines(t, lf), "first")
+	})
+
+	t.Run("line completed by a later write", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		lf := &logFollower{path: path}
+		defer lf.close()
+
+		appendFile(t, path, "done\npar")
+		expectLines(t, pollLines(t, lf), "done")
+		appendFile(t, path, "ti")
+		expectLines(t, pollLines(t, lf))
+		appendFile(t, path, "al\n")
+		expectLines(t, pollLines(t, lf), "partial")
+	})
+
+	t.Run("file renamed and recreated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		lf := &logFollower{path: path}
+		defer lf.close()
+
+		appendFile(t, path, "before\n")
+		expectLines(t, pollLines(t, lf), "before")
+
+		// The end of the old file, including a line left without its
+		// newline, is read before the new file
+		appendFile(t, path, "last\nunfinished")
+		if err := os.Rename(path, path+".1"); err != nil {
+			t.Fatalf("Rename failed: %v", err)
+		}
+		expectLines(t, pollLines(t, lf), "last")
+		appendFile(t, path, "after\n")
+		expectLines(t, pollLines(t, lf), "unfinished", "after")
+		appendFile(t, path, "more\n")
+		expectLines(t, pollLines(t, lf), "more")
+	})
+
+	t.Run("file truncated in place", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		lf := &logFollower{path: path}
+		defer lf.close()
+
+		appendFile(t, path, "one\ntwo\n")
+		expectLines(t, pollLines(t, lf), "one", "two")
+		if err := os.WriteFile(path, []byte("new\n"), 0644); err != nil {
+			t.Fatalf("Failed to truncate: %v", err)
+		}
+		expectLines(t, pollLines(t, lf), "new")
+		appendFile(t, path, "next\n")
+		expectLines(t, pollLines(t, lf), "next")
+	})
+}
+
+func TestTailMatcher(t *testing.T) {
+	step := regexp.MustCompile(`^step`)
+	tests := []struct {
+		name     string
+		matcher  tailMatcher
+		lines    []string
+		ended    int // Index of the line that ends the bar, or -1
+		steps    int64
+		finished bool
+		err      string
+	}{
+		{
+			name:    "counts steps without a total",
+			matcher: tailMatcher{step: step},
+			lines:   []string{"step 1", "noise", "step 2"},
+			ended:   -1,
+			steps:   2,
+		},
+		{
+			name:     "finishes at the total",
+			matcher:  tailMatcher{step: step, total: 2},
+			lines:    []string{"step 1", "noise", "step 2"},
+			ended:    2,
+			steps:    2,
+			finished: true,
+		},
+		{
+			name:     "finishes on done",
+			matcher:  tailMatcher{step: step, done: regexp.MustCompile(`DONE`), total: 5},
+			lines:    []string{"step 1", "all DONE"},
+			ended:    1,
+			steps:    1,
+			finished: true,
+		},
+		{
+			name:    "keeps going past the total until done",
+			matcher: tailMatcher{step: step, done: regexp.MustCompile(`DONE`), total: 1},
+			lines:   []string{"step 1", "step 2"},
+			ended:   -1,
+			steps:   2,
+		},
+		{
+			name:    "fails with the matching line",
+			matcher: tailMatcher{step: step, fail: regexp.MustCompile(`ERROR`)},
+			lines:   []string{"step 1", "  ERROR: disk full  "},
+			ended:   1,
+			steps:   1,
+			err:     "ERROR: disk full",
+		},
+		{
+			name: "fail wins over done and step",
+			matcher: tailMatcher{
+				step: regexp.MustCompile(`.`),
+				done: regexp.MustCompile(`DONE`),
+				fail: regexp.MustCompile(`ERROR`),
+			},
+			lines: []string{"DONE with ERROR"},
+			ended: 0,
+			err:   "DONE with ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.matcher
+			ended := -1
+			for i, line := range tt.lines {
+				if m.match(line) {
+					ended = i
+					break
+				}
+			}
+			if ended != tt.ended {
+				t.Errorf("Expected the bar to end on line %d, ended on %d", tt.ended, ended)
+			}
+			if m.steps != tt.steps || m.finished != tt.finished {
+				t.Errorf("Expected steps=%d finished=%v, got steps=%d finished=%v", tt.steps, tt.finished, m.steps, m.finished)
+			}
+			if (m.err == nil) != (tt.err == "") || (m.err != nil && m.err.Error() != tt.err) {
+				t.Errorf("Expected error %q, got %v", tt.err, m.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// untilLayouts are the accepted formats for "timer --until". Layouts
+// without a date refer to the next time the clock shows that time.
+var untilLayouts = []string{"15:04", "15:04:05", "2006-01-02 15:04", "2006-01-02 15:04:05", time.RFC3339}
+
+// runTimer draws a bar that fills over a span of wall-clock time, showing
+// the time left in place of the ETA.
+func runTimer(args []string) int {
+	fs := flag.NewFlagSet("timer", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pbar timer <duration> | pbar timer --until <time> [flags]\n")
+		fs.PrintDefaults()
+	}
+
+	bf := addBarFlags(fs)
+	bf.addFormatFlag(fs)
+	until := fs.String("until", "", "Run until this time of day (e.g. 14:30) or date and time (e.g. '2024-05-01 14:30')")
+	fs.Parse(args)
+
+	start := time.Now()
+	var deadline time.Time
+	switch {
+	case *until != "" && fs.NArg() == 0:
+		var err error
+		if deadline, err = parseUntil(*until, start); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	case *until == "" && fs.NArg() == 1:
+		d, err := parseTimerDuration(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		deadline = start.Add(d)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	span := deadline.Sub(start)
+	bar, err := bf.newBar(span.Milliseconds())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	bar.StartTime = start
+	bar.Deadline = deadline
+	if !fs.Changed("show-throughput") {
+		bar.ShowThroughput = false // Always one second per second
+	}
+
+	ticker := startStreamTicker(bar, bf.interval)
+	for {
+		remaining := time.Until(deadline)
+		ticker.Update(func(b *pbar.Bar) {
+			b.Current = (span - remaining).Milliseconds()
+		})
+		if remaining <= 0 {
+			break
+		}
+		time.Sleep(min(remaining, bf.interval))
+	}
+	return stopStreamTicker(ticker, nil)
+}
+
+// parseTimerDuration accepts Go durations such as "5m" or "1h30m", and plain
+// numbers of seconds.
+func parseTimerDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration '%s' (e.g. 90, 5m, 1h30m)", s)
+	}
+	return d, nil
+}
+
+// parseUntil resolves an --until value in the local time zone. A time of day
+// that has already passed today means tomorrow.
+func parseUntil(s string, now time.Time) (time.Time, error) {
+	for _, layout := range untilLayouts {
+		t, err := time.ParseInLocation(layout, s, now.Location())
+		if err != nil {
+			continue
+		}
+		if t.Year() == 0 {
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("--until '%s' is in the past", s)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --until '%s' (e.g. 14:30, 14:30:00, '2024-05-01 14:30')", s)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUntil(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, zone)
+	tests := []struct {
+		name     string
+		input    string
+		now      time.Time
+		expected time.Time // Zero if an error is expected
+	}{
+		{"time later today", "14:30", noon, time.Date(2024, 5, 1, 14, 30, 0, 0, zone)},
+		{"time with seconds", "14:30:15", noon, time.Date(2024, 5, 1, 14, 30, 15, 0, zone)},
+		{"time already passed rolls over to tomorrow", "09:00", noon, time.Date(2024, 5, 2, 9, 0, 0, 0, zone)},
+		{"current time rolls over to tomorrow", "12:00", noon, time.Date(2024, 5, 2, 12, 0, 0, 0, zone)},
+		{"rollover into the next month", "01:00", time.Date(2024, 5, 31, 23, 0, 0, 0, zone), time.Date(2024, 6, 1, 1, 0, 0, 0, zone)},
+		{"date and time", "2024-05-01 14:30", noon, time.Date(2024, 5, 1, 14, 30, 0, 0, zone)},
+		{"date and time with seconds", "2024-05-03 08:00:30", noon, time.Date(2024, 5, 3, 8, 0, 30, 0, zone)},
+		{"RFC 3339 in another zone", "2024-05-01T13:00:00Z", noon, time.Date(2024, 5, 1, 15, 0, 0, 0, zone)},
+		{"date and time in the past", "2024-05-01 09:00", noon, time.Time{}},
+		{"date in the past", "2024-04-30 14:30", noon, time.Time{}},
+		{"invalid time", "25:00", noon, time.Time{}},
+		{"not a time", "soon", noon, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := parseUntil(tt.input, tt.now)
+			if tt.expected.IsZero() {
+				if err == nil {
+					t.Errorf("Expected an error for '%s', got %v", tt.input, actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUntil('%s') failed: %v", tt.input, err)
+			}
+			if !actual.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseTimerDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration // 0 if an error is expected
+	}{
+		{"90", 90 * time.Second},
+		{"1.5", 1500 * time.Millisecond},
+		{"5m", 5 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"-5m", 0},
+		{"0s", 0},
+		{"later", 0},
+	}
+	for _, tt := range tests {
+		actual, err := parseTimerDuration(tt.input)
+		if tt.expected == 0 {
+			if err == nil {
+				t.Errorf("Expected an error for '%s', got %v", tt.input, actual)
+			}
+			continue
+		}
+		if err != nil || actual != tt.expected {
+			t.Errorf("parseTimerDuration('%s') = %v, %v; expected %v", tt.input, actual, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCountMatches(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	files := []struct {
+		name string
+		age  time.Duration // Before base
+	}{
+		{"frame_01.png", 3 * time.Minute},
+		{"frame_02.png", 1 * time.Minute},
+		{"frame_03.png", 2 * time.Minute},
+		{"notes.txt", 0},
+		{"frame_100.png", 5 * time.Minute},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", f.name, err)
+		}
+		mtime := base.Add(-f.age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+	// Directories and symlinks are not counted, even when they match
+	if err := os.Mkdir(filepath.Join(dir, "frame_04.png"), 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	if err := os.Symlink("frame_01.png", filepath.Join(dir, "frame_05.png")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	tests := []struct {
+		pattern string
+		count   int64
+		newest  string
+	}{
+		{"*", 5, "notes.txt"},
+		{"*.png", 4, "frame_02.png"},
+		{"frame_??.png", 3, "frame_02.png"},
+		{"frame_1*", 1, "frame_100.png"},
+		{"*.jpg", 0, ""},
+	}
+	for _, tt := range tests {
+		count, newest, err := countMatches(dir, tt.pattern)
+		if err != nil {
+			t.Fatalf("countMatches(%q) failed: %v", tt.pattern, err)
+		}
+		if count != tt.count || newest != tt.newest {
+			t.Errorf("countMatches(%q) = %d, %q; expected %d, %q", tt.pattern, count, newest, tt.count, tt.newest)
+		}
+	}
+
+	if _, _, err := countMatches(filepath.Join(dir, "missing"), "*"); err == nil {
+		t.Errorf("Expected an error for a missing directory")
+	}
+}
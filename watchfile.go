@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// runWatchFile polls the size of a file written by another program and
+// draws its progress towards an expected size.
+func runWatchFile(args []string) int {
+	fs := flag.NewFlagSet("watch-file", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: pbar watch-file <file> --size <size> [flags]\n")
+		fs.PrintDefaults()
+	}
+
+	bf := addBarFlags(fs)
+	bf.addFormatFlag(fs)
+	sizeSpec := fs.String("size", "", "Expected final size of the file (e.g. 2G)")
+	poll := fs.Duration("poll", time.Second, "Time between size checks")
+	stallTimeout := fs.Duration("stall-timeout", 0, "Fail if the file stops growing for this long (0 disables)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	if *sizeSpec == "" {
+		fmt.Fprintf(os.Stderr, "Error: --size is required\n")
+		return 2
+	}
+	size, err := pbar.ParseSize(*sizeSpec)
+	if err != nil || size <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --size '%s'\n", *sizeSpec)
+		return 2
+	}
+	bar, err := bf.newBar(size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	bar.Unit = pbar.UnitBytes
+
+	ticker := startStreamTicker(bar, bf.interval)
+	return stopStreamTicker(ticker, watchFile(path, size, *poll, *stallTimeout, ticker))
+}
+
+// watchFile feeds the size of path to the ticker's bar until it reaches
+// size. A file that does not exist yet counts as empty.
+func watchFile(path string, size int64, poll, stallTimeout time.Duration, ticker *pbar.Ticker) error {
+	var lastSize int64 = -1
+	lastGrowth := time.Now()
+	for {
+		info, err := os.Stat(path)
+		var current int64
+		switch {
+		case err == nil:
+			current = info.Size()
+		case !os.IsNotExist(err):
+			return err
+		}
+
+		ticker.Update(func(b *pbar.Bar) {
+			if lastSize < 0 {
+				// Data written before we started watching is not throughput
+				b.PreviousCurrent = current
+			}
+			b.Current = current
+		})
+		if current >= size {
+			return nil
+		}
+
+		if current != lastSize {
+			lastSize = current
+			lastGrowth = time.Now()
+		} else if stallTimeout > 0 && time.Since(lastGrowth) > stallTimeout {
+			return fmt.Errorf("%s stopped growing for %s", path, stallTimeout)
+		}
+		time.Sleep(poll)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gregory-chatelier/pbar/pbar"
+)
+
+// growFile appends step bytes to path every interval until it holds size bytes.
+func growFile(t *testing.T, path string, size, step int, interval time.Duration) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Errorf("Failed to open %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+	for written := 0; written < size; written += step {
+		time.Sleep(interval)
+		if _, err := f.Write(make([]byte, step)); err != nil {
+			t.Errorf("Failed to write %s: %v", path, err)
+			return
+		}
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	t.Run("file growing to its size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "download.bin")
+		if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		grown := make(chan struct{})
+		go func() {
+			defer close(grown)
+			growFile(t, path, 90, 10, 5*time.Millisecond)
+		}()
+		defer func() { <-grown }()
+
+		ticker := pbar.NewTicker(&pbar.Bar{Total: 100, Width: 10}, &bytes.Buffer{}, time.Hour)
+		if err := watchFile(path, 100, 2*time.Millisecond, time.Second, ticker); err != nil {
+			t.Fatalf("watchFile failed: %v", err)
+		}
+		ticker.Update(func(b *pbar.Bar) {
+			if b.Current != 100 {
+				t.Errorf("Expected the bar at 100, got %d", b.Current)
+			}
+		})
+	})
+
+	t.Run("file created after watching starts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "download.bin")
+		grown := make(chan struct{})
+		go func() {
+			defer close(grown)
+			growFile(t, path, 100, 50, 20*time.Millisecond)
+		}()
+		defer func() { <-grown }()
+
+		ticker := pbar.NewTicker(&pbar.Bar{Total: 100, Width: 10}, &bytes.Buffer{}, time.Hour)
+		if err := watchFile(path, 100, 2*time.Millisecond, time.Second, ticker); err != nil {
+			t.Fatalf("watchFile failed: %v", err)
+		}
+	})
+
+	t.Run("file that stops growing", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "download.bin")
+		if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+
+		ticker := pbar.NewTicker(&pbar.Bar{Total: 100, Width: 10}, &bytes.Buffer{}, time.Hour)
+		start := time.Now()
+		err := watchFile(path, 100, 2*time.Millisecond, 30*time.Millisecond, ticker)
+		if err == nil || !strings.Contains(err.Error(), "stopped growing for 30ms") {
+			t.Fatalf("Expected a stall error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+			t.Errorf("Expected to wait for the stall timeout, returned after %s", elapsed)
+		}
+		ticker.Update(func(b *pbar.Bar) {
+			if b.Current != 10 {
+				t.Errorf("Expected the bar at 10, got %d", b.Current)
+			}
+		})
+	})
+}